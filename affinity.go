@@ -0,0 +1,73 @@
+package sync
+
+import "context"
+
+// BorrowAffine obtains an item from the pool like BorrowErr, preferring
+// hint, e.g. the item a request loop used last, if it is idle, for the
+// sake of CPU cache and connection locality. If hint is borrowed, gone or
+// no longer usable, another item is borrowed as usual. Only Deterministic
+// pools can pick an idle item, and hint must be an item that can be told
+// apart from equal values.
+func (p *Pool[T]) BorrowAffine(ctx context.Context, hint T) (T, error) {
+	var req borrowReq
+	if hasIdentity(any(hint)) {
+		req.hint = any(hint)
+	}
+	return p.borrowItem(ctx, req)
+}
+
+// pickIdle takes the idle item key out of the pool, if it is idle and
+// still usable.
+func (p *Pool[T]) pickIdle(key any) (*entry[T], bool) {
+	s, ok := p.idle.(pickingStore[T])
+	if !ok {
+		return nil, false
+	}
+	e, ok := s.pick(key)
+	if !ok {
+		return nil, false
+	}
+	p.idleCount.Add(-1)
+	p.unretain(e)
+	p.wakeReplenisher()
+	if p.usable(e) || p.refreshed(e) {
+		p.hits.Add(1)
+		return e, true
+	}
+	p.destroy(e)
+	return nil, false
+}
+
+// pickingStore is implemented by stores which can hand out a particular
+// idle item.
+type pickingStore[T any] interface {
+	// pick takes the idle item key out of the store, if it is idle.
+	pick(key any) (*entry[T], bool)
+}
+
+func (s *SliceStore[T]) pick(key any) (*entry[T], bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		e := s.entries[i]
+		if any(e.item) != key {
+			continue
+		}
+		// keep the order the entries were put in
+		copy(s.entries[i:], s.entries[i+1:])
+		s.entries[len(s.entries)-1] = nil
+		s.entries = s.entries[:len(s.entries)-1]
+		return e, true
+	}
+	return nil, false
+}
+
+func (s *shardedStore[T]) pick(key any) (*entry[T], bool) {
+	for _, shard := range s.shards {
+		if e, ok := shard.pick(key); ok {
+			return e, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,64 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestPool_BorrowAffine(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should hand out the hinted item if it is idle", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+
+		// LIFO would hand out the last one returned
+		worker, err := itemPool.BorrowAffine(ctx, workers[0])
+		assert.NoError(t, err)
+		assert.Same(t, workers[0], worker)
+		assert.Equal(t, 2, itemPool.Stats().Idle)
+		assert.Equal(t, uint64(3), itemPool.Stats().Created)
+
+		// the hint is borrowed, another idle item is taken instead
+		other, err := itemPool.BorrowAffine(ctx, workers[0])
+		assert.NoError(t, err)
+		assert.Same(t, workers[2], other)
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(other)
+	})
+	t.Run("should borrow as usual for an unknown hint", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, err := itemPool.BorrowAffine(ctx, &Worker{})
+		assert.NoError(t, err)
+		assert.NotNil(t, worker)
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should borrow as usual with Ephemeral retention", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, err := itemPool.BorrowAffine(ctx, nil)
+		assert.NoError(t, err)
+		assert.NotNil(t, worker)
+	})
+}
@@ -0,0 +1,115 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithMinIdle keeps at least n idle items ready in the pool. Once a factory
+// is set, a background goroutine creates items whenever fewer than n are
+// idle, without ever growing the pool beyond its max size. The goroutine
+// stops when the pool is closed.
+func WithMinIdle[T any](n int) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.minIdle = n
+	}
+}
+
+// startBackground starts the goroutines maintaining the pool.
+func (p *Pool[T]) startBackground() {
+	if p.minIdle > 0 {
+		p.background.Add(1)
+		go p.replenish()
+	}
+	if s, ok := p.idle.(expiringStore[T]); ok && p.maxIdleTime > 0 {
+		p.background.Add(1)
+		go p.reap(s, p.maxIdleTime, &p.idleCount)
+	}
+	if p.tier != nil && p.tier.maxIdle > 0 {
+		p.background.Add(1)
+		go p.reap(p.tier.idle, p.tier.maxIdle, &p.tier.idleCount)
+	}
+}
+
+// wakeReplenisher wakes up the replenisher when fewer than minIdle items
+// are idle.
+func (p *Pool[T]) wakeReplenisher() {
+	if p.minIdle > 0 && p.idleCount.Load() < int32(p.minIdle) {
+		select {
+		case p.lowIdle <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// replenish keeps minIdle items idle until the pool is closed.
+func (p *Pool[T]) replenish() {
+	defer p.background.Done()
+	for {
+		p.fill()
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.lowIdle:
+		}
+	}
+}
+
+// fill creates idle items until minIdle are available or the pool is full.
+func (p *Pool[T]) fill() {
+	for p.idleCount.Load() < int32(p.minIdle) && p.ctx.Err() == nil {
+		// hold slots while creating so borrowers can't grow the pool past
+		// its max size in the meantime
+		n := p.reserve(p.minIdle - int(p.idleCount.Load()))
+		if n <= 0 {
+			return
+		}
+		entries, err := p.createMany(p.ctx, n)
+		kept := false
+		for _, e := range entries {
+			kept = p.putIdle(e) || kept
+		}
+		p.release(int64(n))
+		if err != nil {
+			p.warn("sync: replenishing idle items failed", "err", err)
+			return
+		}
+		if !kept {
+			// e.g. the idle items would take more than the max bytes
+			return
+		}
+	}
+}
+
+// reap evicts the items of s idle for longer than maxIdle until the pool
+// is closed, discounting them from idle. Rather than ticking, it sleeps
+// until the oldest idle item is due to expire.
+func (p *Pool[T]) reap(s expiringStore[T], maxIdle time.Duration, idle *atomic.Int32) {
+	defer p.background.Done()
+	timer := time.NewTimer(maxIdle)
+	defer timer.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+		expired, oldest := s.expire(now.Add(-maxIdle))
+		for _, e := range expired {
+			idle.Add(-1)
+			p.unretain(e)
+			p.destroy(e)
+		}
+		if len(expired) > 0 {
+			p.wakeReplenisher()
+		}
+
+		next := maxIdle
+		if !oldest.IsZero() {
+			next = oldest.Add(maxIdle).Sub(now)
+		}
+		timer.Reset(next)
+	}
+}
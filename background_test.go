@@ -0,0 +1,103 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_WithMinIdle(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should keep min idle items ready within max size", func(t *testing.T) {
+		var created atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithMinIdle[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			created.Add(1)
+			return &Worker{id: rand.Intn(1000)}
+		})
+		assert.Eventually(t, func() bool {
+			return itemPool.Stats().Idle == 2
+		}, time.Second, time.Millisecond)
+
+		worker1 := itemPool.Borrow(ctx)
+		assert.Eventually(t, func() bool {
+			return itemPool.Stats().Idle == 2
+		}, time.Second, time.Millisecond)
+
+		// max size leaves room for a single idle item only
+		worker2 := itemPool.Borrow(ctx)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, int32(3), itemPool.Count())
+		assert.Equal(t, int32(3), created.Load())
+
+		itemPool.ReturnItem(worker1)
+		itemPool.ReturnItem(worker2)
+		assert.NoError(t, itemPool.Close())
+		assert.Equal(t, int32(0), itemPool.Count())
+	})
+}
+
+func TestPool_WithMaxIdleTime(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should reap items idle for too long in the background", func(t *testing.T) {
+		var destroyed atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithMaxIdleTime[*Worker](30*time.Millisecond),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed.Add(1)
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+		assert.Eventually(t, func() bool {
+			return itemPool.Stats().Idle == 0
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, int32(2), destroyed.Load())
+		assert.Equal(t, int32(1), itemPool.Count())
+
+		itemPool.ReturnItem(worker)
+		assert.NoError(t, itemPool.Close())
+	})
+}
+
+func TestPool_WithContext(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should stop background goroutines when cancelled", func(t *testing.T) {
+		lifetime, cancel := context.WithCancel(ctx)
+		itemPool := sync.NewPool[*Worker](
+			sync.WithContext[*Worker](lifetime),
+			sync.WithSize[*Worker](3),
+			sync.WithMinIdle[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Eventually(t, func() bool {
+			return itemPool.Stats().Idle == 2
+		}, time.Second, time.Millisecond)
+
+		cancel()
+		worker := itemPool.Borrow(ctx)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, 1, itemPool.Stats().Idle)
+
+		// the pool itself stays usable
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 2, itemPool.Stats().Idle)
+		assert.NoError(t, itemPool.Close())
+	})
+}
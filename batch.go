@@ -0,0 +1,193 @@
+package sync
+
+import (
+	"context"
+	"errors"
+)
+
+// BorrowBatch obtains n items from the pool at once. It blocks until
+// slots for all n items are free, so concurrent batches can't deadlock
+// each other by holding part of what they need. Either all n items are
+// returned, or none and the error: if ctx is done or the factory fails,
+// everything acquired so far is given back.
+//
+// ErrBatchTooLarge is returned if n exceeds the max size of the pool.
+func (p *Pool[T]) BorrowBatch(ctx context.Context, n int) ([]T, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if size := p.size.Load(); size > 0 && n > int(size) {
+		return nil, ErrBatchTooLarge
+	}
+	if err := p.acquireBatch(ctx, int64(n)); err != nil {
+		return nil, aborted(err)
+	}
+
+	entries := make([]*entry[T], 0, n)
+	for len(entries) < n {
+		e, err := p.get(ctx)
+		if err != nil {
+			for _, e := range entries {
+				p.putIdle(e)
+			}
+			p.release(int64(n))
+			return nil, aborted(err)
+		}
+		entries = append(entries, e)
+	}
+	p.raisePeak(p.inUse.Add(int32(n)), int64(n))
+
+	items := make([]T, 0, n)
+	defer func() {
+		if len(items) < n {
+			// a borrow hook panicked, hand back what was not given out
+			for _, item := range items {
+				p.ReturnItem(item)
+			}
+			for _, e := range entries[len(items)+1:] {
+				p.putIdle(e)
+				p.free(1)
+			}
+		}
+	}()
+	for _, e := range entries {
+		items = append(items, p.borrowed(e))
+	}
+	return items, nil
+}
+
+// BorrowBatchPartial obtains between min and max items from the pool at
+// once. Like BorrowBatch, it blocks until slots for min items are free,
+// then borrows further items while slots are free until it has max of
+// them, and returns what it got. Only if ctx has a deadline does it wait
+// for further slots to free up, until that deadline. An error is only
+// returned, along with no items, if fewer than min could be obtained; if
+// the factory fails once min items are obtained, the items obtained so far
+// are returned.
+//
+// ErrBatchTooLarge is returned if min exceeds the max size of the pool,
+// max is limited to that size.
+func (p *Pool[T]) BorrowBatchPartial(ctx context.Context, min, max int) ([]T, error) {
+	if min < 0 {
+		min = 0
+	}
+	size := int(p.size.Load())
+	if size > 0 && min > size {
+		return nil, ErrBatchTooLarge
+	}
+	if size > 0 && max > size {
+		max = size
+	}
+	if max < min {
+		max = min
+	}
+	if max == 0 {
+		return nil, nil
+	}
+	if min > 0 {
+		if err := p.acquireBatch(ctx, int64(min)); err != nil {
+			return nil, aborted(err)
+		}
+	}
+
+	entries := make([]*entry[T], 0, max)
+	for len(entries) < max {
+		if len(entries) >= min {
+			err := p.acquireExtra(ctx)
+			if errors.Is(err, ErrPoolCopied) || errors.Is(err, ErrPoolNotCreated) {
+				// min is 0, so no slots were acquired before
+				return nil, err
+			}
+			if err != nil {
+				break
+			}
+		}
+		e, err := p.get(ctx)
+		if err != nil {
+			if len(entries) < min {
+				for _, e := range entries {
+					p.putIdle(e)
+				}
+				p.release(int64(min))
+				return nil, aborted(err)
+			}
+			p.release(1)
+			break
+		}
+		entries = append(entries, e)
+	}
+	n := len(entries)
+	p.raisePeak(p.inUse.Add(int32(n)), int64(n))
+
+	items := make([]T, 0, n)
+	defer func() {
+		if len(items) < n {
+			// a borrow hook panicked, hand back what was not given out
+			for _, item := range items {
+				p.ReturnItem(item)
+			}
+			for _, e := range entries[len(items)+1:] {
+				p.putIdle(e)
+				p.free(1)
+			}
+		}
+	}()
+	for _, e := range entries {
+		items = append(items, p.borrowed(e))
+	}
+	return items, nil
+}
+
+// WithFairness makes batch borrows acquire their slots one batch at a
+// time.
+//
+// Slots are always granted in arrival order, and a borrower waiting for
+// several slots is never overtaken by later, smaller requests. With
+// fairness enabled, a batch additionally waits for earlier batches to be
+// served before it queues for slots itself, so that concurrent batches
+// can't collectively hold back capacity from each other. The tradeoff is
+// latency: a batch waits for every batch queued before it, and borrows
+// arriving while a batch waits are held back until it is served.
+func WithFairness[T any](fair bool) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.fair = fair
+	}
+}
+
+// acquireBatch reserves slots for a batch of n items, taking turns with
+// other batches when fairness is enabled.
+func (p *Pool[T]) acquireBatch(ctx context.Context, n int64) error {
+	if p.fair {
+		select {
+		case p.batchTurn <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		defer func() {
+			<-p.batchTurn
+		}()
+	}
+	_, err := p.admit(ctx, borrowReq{weight: n, batch: true})
+	return err
+}
+
+// acquireExtra reserves a slot for an item of a partial batch beyond its
+// min. It only waits for one if ctx has a deadline.
+func (p *Pool[T]) acquireExtra(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := p.admit(ctx, borrowReq{batch: true, noWait: true})
+	if _, ok := ctx.Deadline(); ok && errors.Is(err, ErrWouldBlock) {
+		_, err = p.admit(ctx, borrowReq{batch: true})
+	}
+	return err
+}
+
+// ReturnBatch returns several items back to the pool, as if ReturnItem
+// was called on each of them. A nil or empty slice is a no-op.
+func (p *Pool[T]) ReturnBatch(items []T) {
+	for _, item := range items {
+		p.ReturnItem(item)
+	}
+}
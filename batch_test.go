@@ -0,0 +1,231 @@
+package sync_test
+
+import (
+	"context"
+	"errors"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPool_BorrowBatch(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should borrow all items at once", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		assert.Len(t, workers, 3)
+		assert.Equal(t, 3, itemPool.Stats().InUse)
+
+		_, err = itemPool.BorrowBatch(ctx, 4)
+		assert.ErrorIs(t, err, sync.ErrBatchTooLarge)
+		for _, worker := range workers {
+			itemPool.ReturnItem(worker)
+		}
+	})
+	t.Run("should not take part of a batch when context is done", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		workers, err := itemPool.BorrowBatch(timeoutCtx, 3)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, workers)
+
+		// the two free slots are still available
+		workers, err = itemPool.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+		assert.Len(t, workers, 2)
+		for _, worker := range append(workers, worker) {
+			itemPool.ReturnItem(worker)
+		}
+	})
+	t.Run("should give everything back when the factory fails", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+		)
+		dialErr := errors.New("dial failed")
+		created := 0
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			created++
+			if created == 2 {
+				return nil, dialErr
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.ErrorIs(t, err, dialErr)
+		assert.Nil(t, workers)
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+
+		workers, err = itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		for _, worker := range workers {
+			itemPool.ReturnItem(worker)
+		}
+	})
+}
+
+func TestPool_BorrowBatchPartial(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should keep what it got once the context is done", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		workers, err := itemPool.BorrowBatchPartial(timeoutCtx, 2, 4)
+		assert.NoError(t, err)
+		assert.Len(t, workers, 3)
+		assert.Equal(t, 4, itemPool.Stats().InUse)
+
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnBatch(workers)
+		stats := itemPool.Stats()
+		assert.Equal(t, 0, stats.InUse)
+		workers, err = itemPool.BorrowBatch(ctx, 4)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+	})
+	t.Run("should give everything back with fewer than min items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		held, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		workers, err := itemPool.BorrowBatchPartial(timeoutCtx, 2, 4)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, workers)
+
+		// the free slot is still available
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnBatch(held)
+	})
+	t.Run("should borrow max items when they are free", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatchPartial(ctx, 1, 6)
+		assert.NoError(t, err)
+		assert.Len(t, workers, 4)
+
+		_, err = itemPool.BorrowBatchPartial(ctx, 5, 6)
+		assert.ErrorIs(t, err, sync.ErrBatchTooLarge)
+		itemPool.ReturnBatch(workers)
+	})
+	t.Run("should not wait for more than min items without a deadline", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		held, err := itemPool.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+
+		start := time.Now()
+		workers, err := itemPool.BorrowBatchPartial(ctx, 1, 4)
+		assert.NoError(t, err)
+		assert.Len(t, workers, 2)
+		assert.Less(t, time.Since(start), 50*time.Millisecond)
+		itemPool.ReturnBatch(workers)
+		itemPool.ReturnBatch(held)
+	})
+}
+
+func TestPool_ReturnBatch(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should run hooks and free slots for every item", func(t *testing.T) {
+		resets := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithResetFunc[*Worker](func(w *Worker) {
+				resets++
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+
+		itemPool.ReturnBatch(workers)
+		assert.Equal(t, 3, resets)
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+
+		itemPool.ReturnBatch(nil)
+		workers, err = itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+	})
+}
+
+func TestPool_WithFairness(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should serve a waiting batch before later borrowers", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithFairness[*Worker](true),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		held, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+
+		batch := make(chan []*Worker)
+		go func() {
+			workers, _ := itemPool.BorrowBatch(ctx, 3)
+			batch <- workers
+		}()
+		assert.Eventually(t, func() bool {
+			return itemPool.Waiters() == 1
+		}, time.Second, time.Millisecond)
+
+		// single borrows can't sneak in while slots free up one by one
+		single := make(chan *Worker)
+		go func() {
+			single <- itemPool.Borrow(ctx)
+		}()
+		itemPool.ReturnBatch(held)
+		workers := <-batch
+		assert.Len(t, workers, 3)
+		select {
+		case <-single:
+			assert.Fail(t, "single borrow should wait for the batch")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		itemPool.ReturnBatch(workers)
+		itemPool.ReturnItem(<-single)
+	})
+}
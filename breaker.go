@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithFactoryCircuitBreaker stops calling a failing factory. Once it fails
+// threshold times in a row, borrows which would create an item fail right
+// away with ErrCircuitOpen for cooldown, sparing a backend which is down
+// from a thundering herd. Afterwards a single trial call is let through:
+// if it succeeds the factory is called as usual again, otherwise the
+// breaker stays open for another cooldown. Idle items are still handed out
+// while the breaker is open.
+//
+// Failures of borrows whose context is done by the time the factory
+// returns are not counted, they tell nothing about the factory.
+func WithFactoryCircuitBreaker[T any](threshold int, cooldown time.Duration) PoolOption[T] {
+	return func(p *Pool[T]) {
+		if threshold < 1 {
+			p.invalid("circuit breaker threshold must be at least 1, got %d", threshold)
+			return
+		}
+		p.breaker = &circuitBreaker{threshold: int32(threshold), cooldown: cooldown}
+	}
+}
+
+// circuitBreaker counts consecutive factory failures.
+type circuitBreaker struct {
+	threshold int32
+	cooldown  time.Duration
+
+	failures  atomic.Int32
+	openUntil atomic.Int64 // openUntil is the end of the cooldown in unix nanoseconds, 0 while closed
+	trial     atomic.Bool  // trial is set while the trial call after a cooldown runs
+}
+
+// allow reports whether the factory may be called.
+func (b *circuitBreaker) allow() bool {
+	until := b.openUntil.Load()
+	if until == 0 {
+		return true
+	}
+	if time.Now().UnixNano() < until {
+		return false
+	}
+	// the cooldown is over, let a single trial through
+	return b.trial.CompareAndSwap(false, true)
+}
+
+// record records the outcome of a factory call. Calls whose borrower gave
+// up are not counted.
+func (b *circuitBreaker) record(err error, gaveUp bool) {
+	switch {
+	case err == nil:
+		b.failures.Store(0)
+		b.openUntil.Store(0)
+		b.trial.Store(false)
+	case gaveUp:
+		// let another trial through if this one was abandoned
+		b.trial.Store(false)
+	case b.trial.Load() || b.failures.Add(1) >= b.threshold:
+		b.openUntil.Store(time.Now().Add(b.cooldown).UnixNano())
+		b.trial.Store(false)
+	}
+}
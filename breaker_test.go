@@ -0,0 +1,80 @@
+package sync_test
+
+import (
+	"context"
+	"errors"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPool_WithFactoryCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should fail fast while the breaker is open", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactoryCircuitBreaker[*Worker](2, 50*time.Millisecond),
+		)
+		calls := 0
+		down := true
+		refused := errors.New("connection refused")
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			calls++
+			if down {
+				return nil, refused
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		for i := 0; i < 2; i++ {
+			_, err := itemPool.BorrowErr(ctx)
+			assert.ErrorIs(t, err, refused)
+		}
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrCircuitOpen)
+		assert.Equal(t, 2, calls)
+
+		// a failed trial opens the breaker again
+		time.Sleep(60 * time.Millisecond)
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, refused)
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrCircuitOpen)
+		assert.Equal(t, 3, calls)
+
+		// a successful trial closes it
+		time.Sleep(60 * time.Millisecond)
+		down = false
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 4, calls)
+	})
+	t.Run("should keep handing out idle items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactoryCircuitBreaker[*Worker](1, time.Minute),
+		)
+		fail := false
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			if fail {
+				return nil, errors.New("connection refused")
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		worker := itemPool.Borrow(ctx)
+		fail = true
+		_, err := itemPool.BorrowErr(ctx)
+		assert.Error(t, err)
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrCircuitOpen)
+
+		itemPool.ReturnItem(worker)
+		assert.Same(t, worker, itemPool.Borrow(ctx))
+	})
+	t.Run("should reject a threshold below 1", func(t *testing.T) {
+		_, err := sync.NewPoolErr[*Worker](sync.WithFactoryCircuitBreaker[*Worker](0, time.Second))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+}
@@ -0,0 +1,14 @@
+//go:build vetcopy
+
+package sync_test
+
+import (
+	"github.com/kushsharma/go-sync"
+)
+
+// copyPool copies a Pool by value, which go vet reports. It is only built
+// with the vetcopy tag, see TestPool_Copy.
+func copyPool() sync.Pool[*Worker] {
+	itemPool := sync.NewPool[*Worker]()
+	return *itemPool
+}
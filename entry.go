@@ -0,0 +1,144 @@
+package sync
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// entry is the bookkeeping a Pool keeps alongside each of its items.
+type entry[T any] struct {
+	item       T
+	createdAt  time.Time
+	idleSince  time.Time
+	generation uint64
+	uses       int   // uses counts how often the item was borrowed
+	weight     int64 // weight is the number of slots the borrowed item holds
+	overflow   bool  // overflow items hold no slot and are never kept
+	tier       bool  // tier items hold a slot of the overflow tier
+
+	// only kept while borrowed with leak detection or debugging enabled
+	borrowedAt  time.Time
+	borrowStack callStack
+	leakTimer   *time.Timer
+	watchdog    *time.Timer
+	borrower    uint64 // borrower is the holding goroutine, with WithReentrancyGuard
+}
+
+func newEntry[T any](item T) *entry[T] {
+	return &entry[T]{item: item, createdAt: time.Now()}
+}
+
+// ledger remembers which items are borrowed, so that returning an item
+// twice is noticed and its bookkeeping is found again when it is returned.
+// Items for which hasIdentity holds are recorded one by one; other items
+// are only counted. Recorded items are spread over independently locked
+// parts by address.
+type ledger[T any] struct {
+	parts     []ledgerPart[T]
+	anonymous atomic.Int64 // anonymous counts borrowed items without identity
+}
+
+type ledgerPart[T any] struct {
+	mu      sync.Mutex
+	entries map[any]*entry[T]
+}
+
+// init splits the ledger into n parts.
+func (l *ledger[T]) init(n int) {
+	if n < 1 {
+		n = 1
+	}
+	l.parts = make([]ledgerPart[T], n)
+	for i := range l.parts {
+		l.parts[i].entries = make(map[any]*entry[T])
+	}
+}
+
+// part returns the part recording key.
+func (l *ledger[T]) part(key any) *ledgerPart[T] {
+	if len(l.parts) == 1 {
+		return &l.parts[0]
+	}
+	addr := reflect.ValueOf(key).Pointer()
+	return &l.parts[(addr>>4)%uintptr(len(l.parts))]
+}
+
+// checkout records a borrowed item.
+func (l *ledger[T]) checkout(e *entry[T]) {
+	key := any(e.item)
+	if !hasIdentity(key) {
+		l.anonymous.Add(1)
+		return
+	}
+	part := l.part(key)
+	part.mu.Lock()
+	part.entries[key] = e
+	part.mu.Unlock()
+}
+
+// checkin forgets a returned item, reporting false if it was not borrowed.
+// The entry is nil for items without identity.
+func (l *ledger[T]) checkin(item T) (*entry[T], bool) {
+	key := any(item)
+	if !hasIdentity(key) {
+		for {
+			n := l.anonymous.Load()
+			if n <= 0 {
+				return nil, false
+			}
+			if l.anonymous.CompareAndSwap(n, n-1) {
+				return nil, true
+			}
+		}
+	}
+	part := l.part(key)
+	part.mu.Lock()
+	e, ok := part.entries[key]
+	delete(part.entries, key)
+	part.mu.Unlock()
+	return e, ok
+}
+
+// each calls fn on every recorded entry while holding the lock of its part.
+func (l *ledger[T]) each(fn func(e *entry[T])) {
+	for i := range l.parts {
+		part := &l.parts[i]
+		part.mu.Lock()
+		for _, e := range part.entries {
+			fn(e)
+		}
+		part.mu.Unlock()
+	}
+}
+
+// hasIdentity reports whether item can be told apart from equal values.
+func hasIdentity(item any) bool {
+	t := reflect.TypeOf(item)
+	if t == nil {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// isValueType reports whether items of type T are plain values, which have
+// no identity of their own. Interface types may hold either and are not
+// considered values.
+func isValueType[T any]() bool {
+	switch reflect.TypeOf((*T)(nil)).Elem().Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Chan, reflect.UnsafePointer, reflect.Interface:
+		return false
+	}
+	return true
+}
+
+// isNil reports whether item is nil, including nil pointers, maps and
+// channels.
+func isNil(item any) bool {
+	return item == nil || hasIdentity(item) && reflect.ValueOf(item).IsNil()
+}
@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPoolClosed is returned when borrowing from a pool that has been closed.
+var ErrPoolClosed = errors.New("sync: pool is closed")
+
+// ErrNoFactory is returned when borrowing from a pool that has no factory
+// to create items with.
+var ErrNoFactory = errors.New("sync: pool has no factory, use WithFactory or SetFactory")
+
+// ErrFactoryPanic is returned when the factory panicked while creating an
+// item. The returned error also describes the panic value.
+var ErrFactoryPanic = errors.New("sync: factory panicked")
+
+// ErrBatchTooLarge is returned when borrowing more items at once than the
+// max size of the pool.
+var ErrBatchTooLarge = errors.New("sync: batch is larger than the pool")
+
+// ErrTooManyWaiters is returned when borrowing from a full pool which
+// already has as many borrowers waiting as WithMaxWaiters allows.
+var ErrTooManyWaiters = errors.New("sync: too many borrowers waiting")
+
+// ErrInvalidConfig is wrapped by the errors describing invalid options.
+var ErrInvalidConfig = errors.New("sync: invalid pool configuration")
+
+// ErrNotBorrowed is returned by ReturnItemErr for an item which is not
+// borrowed, e.g. because it was already returned.
+var ErrNotBorrowed = errors.New("sync: item is not borrowed")
+
+// ErrNilItem is returned by ReturnItemErr for a nil item which was not
+// borrowed. It is dropped and the item actually borrowed stays borrowed.
+var ErrNilItem = errors.New("sync: returned item is nil")
+
+// ErrReentrantBorrow is returned when borrowing from a full pool with
+// WithReentrancyGuard from a goroutine which already holds an item of it.
+var ErrReentrantBorrow = errors.New("sync: reentrant borrow on a full pool")
+
+// ErrCircuitOpen is returned when an item would have to be created while
+// the WithFactoryCircuitBreaker breaker is open.
+var ErrCircuitOpen = errors.New("sync: factory circuit breaker is open")
+
+// ErrWouldBlock is returned when borrowing from a full pool whose
+// WithBorrowFailFast predicate refuses to wait for a slot.
+var ErrWouldBlock = errors.New("sync: borrow would block")
+
+// ErrBorrowAborted is returned when the context of a borrow is done before
+// an item could be obtained. The returned error also wraps the context
+// error, so that errors.Is matches context.Canceled or
+// context.DeadlineExceeded as well.
+var ErrBorrowAborted = errors.New("sync: borrow aborted")
+
+// ErrPoolCopied is returned when borrowing from a copy of a pool rather
+// than the pool NewPool created.
+var ErrPoolCopied = errors.New("sync: pool was copied")
+
+// ErrPoolNotCreated is returned when using the zero value of a Pool rather
+// than a pool NewPool created.
+var ErrPoolNotCreated = errors.New("sync: pool was not created with NewPool")
+
+// aborted wraps a context error ending a borrow with ErrBorrowAborted.
+func aborted(err error) error {
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(err, ErrBorrowAborted) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrBorrowAborted, err)
+}
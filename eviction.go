@@ -0,0 +1,81 @@
+package sync
+
+import "math/rand"
+
+// EvictionPolicy decides which item a Pool drops when an item is returned
+// while MaxIdle items are idle already.
+type EvictionPolicy int
+
+const (
+	// EvictReturned drops the returned item, keeping the idle ones. It is
+	// the default.
+	EvictReturned EvictionPolicy = iota
+	// LRU drops the item idle for the longest time, keeping the most
+	// recently used items warm.
+	LRU
+	// LFU drops the item borrowed the fewest times, keeping the most
+	// frequently used items. Ties drop the item idle for the longest time.
+	LFU
+	// Random drops any of the idle items and the returned one.
+	Random
+)
+
+// WithEvictionPolicy sets which item is dropped once WithMaxIdle items are
+// idle. An eviction policy other than EvictReturned implies Deterministic
+// retention, and with WithShards the dropped item is picked within the
+// shard the returned item goes to. Pools with WithStore drop the returned
+// item.
+func WithEvictionPolicy[T any](policy EvictionPolicy) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.eviction = policy
+	}
+}
+
+// evictingStore is implemented by stores which can pick the idle item an
+// EvictionPolicy drops.
+type evictingStore[T any] interface {
+	// evict puts e into the store in place of the item policy drops, and
+	// returns the dropped item, e itself if the idle items are kept.
+	evict(e *entry[T], policy EvictionPolicy) *entry[T]
+}
+
+func (s *SliceStore[T]) evict(e *entry[T], policy EvictionPolicy) *entry[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	victim := -1
+	switch policy {
+	case LRU:
+		for i, idle := range s.entries {
+			if victim < 0 || idle.idleSince.Before(s.entries[victim].idleSince) {
+				victim = i
+			}
+		}
+	case LFU:
+		for i, idle := range s.entries {
+			if victim < 0 || idle.uses < s.entries[victim].uses ||
+				idle.uses == s.entries[victim].uses && idle.idleSince.Before(s.entries[victim].idleSince) {
+				victim = i
+			}
+		}
+		if victim >= 0 && e.uses < s.entries[victim].uses {
+			victim = -1
+		}
+	case Random:
+		if i := rand.Intn(len(s.entries) + 1); i < len(s.entries) {
+			victim = i
+		}
+	}
+	if victim < 0 {
+		return e
+	}
+	dropped := s.entries[victim]
+	// keep the order the entries were put in
+	copy(s.entries[victim:], s.entries[victim+1:])
+	s.entries[len(s.entries)-1] = e
+	return dropped
+}
+
+func (s *shardedStore[T]) evict(e *entry[T], policy EvictionPolicy) *entry[T] {
+	return s.shards[s.next.Add(1)%uint32(len(s.shards))].evict(e, policy)
+}
@@ -0,0 +1,61 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestPool_WithEvictionPolicy(t *testing.T) {
+	ctx := context.Background()
+	newPool := func(policy sync.EvictionPolicy) *sync.Pool[*Worker] {
+		return sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithMaxIdle[*Worker](2),
+			sync.WithEvictionPolicy[*Worker](policy),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+	}
+	// fill returns three items, the first of which was borrowed twice
+	fill := func(itemPool *sync.Pool[*Worker]) (a, b, c *Worker) {
+		a = itemPool.Borrow(ctx)
+		b = itemPool.Borrow(ctx)
+		c = itemPool.Borrow(ctx)
+		itemPool.ReturnItem(a)
+		a = itemPool.Borrow(ctx)
+		itemPool.ReturnItem(a)
+		itemPool.ReturnItem(b)
+		itemPool.ReturnItem(c)
+		return a, b, c
+	}
+	t.Run("should drop the returned item by default", func(t *testing.T) {
+		itemPool := newPool(sync.EvictReturned)
+		a, b, _ := fill(itemPool)
+		assert.ElementsMatch(t, []*Worker{a, b}, itemPool.IdleItems())
+		assert.Equal(t, uint64(1), itemPool.Stats().Destroyed)
+	})
+	t.Run("should drop the least recently used item with LRU", func(t *testing.T) {
+		itemPool := newPool(sync.LRU)
+		_, b, c := fill(itemPool)
+		assert.ElementsMatch(t, []*Worker{b, c}, itemPool.IdleItems())
+		assert.Equal(t, uint64(1), itemPool.Stats().Destroyed)
+	})
+	t.Run("should drop the least frequently used item with LFU", func(t *testing.T) {
+		itemPool := newPool(sync.LFU)
+		a, _, c := fill(itemPool)
+		assert.ElementsMatch(t, []*Worker{a, c}, itemPool.IdleItems())
+		assert.Equal(t, uint64(1), itemPool.Stats().Destroyed)
+	})
+	t.Run("should keep max idle items with Random", func(t *testing.T) {
+		itemPool := newPool(sync.Random)
+		fill(itemPool)
+		assert.Len(t, itemPool.IdleItems(), 2)
+		assert.Equal(t, 2, itemPool.Stats().Idle)
+		assert.Equal(t, uint64(1), itemPool.Stats().Destroyed)
+	})
+}
@@ -0,0 +1,56 @@
+package sync
+
+import "sync/atomic"
+
+// hook holds a function of a pool which can be swapped while the pool is
+// in use. Calls in flight see either the old or the new function.
+type hook[F any] struct {
+	fn atomic.Pointer[F]
+}
+
+// load returns the function, nil if none is set.
+func (h *hook[F]) load() F {
+	if fn := h.fn.Load(); fn != nil {
+		return *fn
+	}
+	var zero F
+	return zero
+}
+
+func (h *hook[F]) store(fn F) {
+	h.fn.Store(&fn)
+}
+
+// SetResetFunc replaces the function set with WithResetFunc, nil removes
+// it. Returns already in progress may still use the previous function.
+func (p *Pool[T]) SetResetFunc(reset func(T)) {
+	p.reset.store(reset)
+}
+
+// SetOnBorrow replaces the function set with WithOnBorrow, nil removes it.
+// Borrows already in progress may still use the previous function.
+func (p *Pool[T]) SetOnBorrow(fn func(T)) {
+	p.onBorrow.store(fn)
+}
+
+// SetValidateOnBorrow replaces the function set with
+// WithValidateOnBorrow, e.g. to validate strictly during an incident; nil
+// removes it. Borrows already in progress may still use the previous
+// function.
+func (p *Pool[T]) SetValidateOnBorrow(fn func(T) bool) {
+	p.validateOnBorrow.store(fn)
+}
+
+// SetValidateOnReturn replaces the function set with
+// WithValidateOnReturn, nil removes it. Returns already in progress may
+// still use the previous function.
+func (p *Pool[T]) SetValidateOnReturn(fn func(T) bool) {
+	p.validateOnReturn.store(fn)
+}
+
+// SetReturnPolicy replaces the function set with WithReturnPolicy, nil
+// removes it. Returns already in progress may still use the previous
+// function.
+func (p *Pool[T]) SetReturnPolicy(fn func(T) ReturnAction) {
+	p.returnPolicy.store(fn)
+}
@@ -0,0 +1,77 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPool_SetHooks(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should use hooks set after construction", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.SetResetFunc(func(w *Worker) {
+			w.id = 0
+		})
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 0, worker.id)
+
+		// strict validation rejects the idle item
+		itemPool.SetValidateOnBorrow(func(*Worker) bool {
+			return false
+		})
+		assert.NotSame(t, worker, itemPool.Borrow(ctx))
+		assert.Equal(t, uint64(1), itemPool.Stats().Destroyed)
+	})
+	t.Run("should remove a hook set to nil", func(t *testing.T) {
+		var borrowed atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithOnBorrow[*Worker](func(*Worker) {
+				borrowed.Add(1)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		itemPool.SetOnBorrow(nil)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		assert.Equal(t, int32(1), borrowed.Load())
+	})
+	t.Run("should swap hooks while items are borrowed and returned", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				strict := i%2 == 0
+				itemPool.SetValidateOnReturn(func(*Worker) bool {
+					return !strict
+				})
+				itemPool.SetReturnPolicy(func(*Worker) sync.ReturnAction {
+					return sync.Keep
+				})
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			itemPool.ReturnItem(itemPool.Borrow(ctx))
+		}
+		<-done
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+	})
+}
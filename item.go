@@ -0,0 +1,53 @@
+package sync
+
+import (
+	"context"
+	"runtime"
+	"sync/atomic"
+)
+
+// Item is a borrowed item which returns itself to its pool when closed.
+//
+//	item, err := pool.Acquire(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer item.Close()
+type Item[T any] struct {
+	pool     *Pool[T]
+	value    T
+	returned atomic.Bool
+}
+
+// Acquire borrows an item from the pool like BorrowErr, wrapped in a
+// handle that returns it to the pool exactly once when closed.
+func (p *Pool[T]) Acquire(ctx context.Context) (*Item[T], error) {
+	value, err := p.BorrowErr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	item := &Item[T]{pool: p, value: value}
+	if p.leakAfter > 0 {
+		stack := captureStack(1)
+		runtime.SetFinalizer(item, func(item *Item[T]) {
+			if !item.returned.Load() {
+				p.warn("sync: leaked item was garbage collected without being closed", "stack", stack.String())
+			}
+		})
+	}
+	return item, nil
+}
+
+// Value returns the borrowed item.
+func (i *Item[T]) Value() T {
+	return i.value
+}
+
+// Close returns the item to its pool. Only the first call has an effect,
+// so it is safe to both defer Close and call it early on some branch.
+func (i *Item[T]) Close() error {
+	if i.returned.CompareAndSwap(false, true) {
+		i.pool.ReturnItem(i.value)
+	}
+	return nil
+}
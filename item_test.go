@@ -0,0 +1,41 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestPool_Acquire(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should return item to the pool exactly once", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		item, err := itemPool.Acquire(ctx)
+		assert.NoError(t, err)
+		assert.NotNil(t, item.Value())
+		assert.Equal(t, 1, itemPool.Stats().InUse)
+
+		var closer io.Closer = item
+		assert.NoError(t, closer.Close())
+		assert.NoError(t, closer.Close())
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+
+		// a double close must not have freed an extra slot
+		worker1, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		worker2, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker1)
+		itemPool.ReturnItem(worker2)
+	})
+}
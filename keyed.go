@@ -0,0 +1,293 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// KeyedPoolOption configures a KeyedPool.
+type KeyedPoolOption[K comparable, T any] func(*KeyedPool[K, T])
+
+// WithKeyOptions sets the options every sub-pool is created with, e.g.
+// WithSize to limit the number of items per key.
+func WithKeyOptions[K comparable, T any](opts ...PoolOption[T]) KeyedPoolOption[K, T] {
+	return func(p *KeyedPool[K, T]) {
+		p.opts = append(p.opts, opts...)
+	}
+}
+
+// WithKeyIdleTimeout closes the sub-pool of a key once none of its items
+// has been borrowed for d, so that keys which are no longer used don't keep
+// their items around forever. The sub-pool is created again on the next
+// borrow for the key.
+func WithKeyIdleTimeout[K comparable, T any](d time.Duration) KeyedPoolOption[K, T] {
+	return func(p *KeyedPool[K, T]) {
+		p.idleTimeout = d
+	}
+}
+
+// WithMaxTotal limits the number of items borrowed at the same time
+// across all keys to n, on top of the limit of each key. Borrowers block
+// while the limit is reached, like they do on a full Pool. To also bound
+// idle items, limit them per key with WithMaxIdle.
+func WithMaxTotal[K comparable, T any](n int) KeyedPoolOption[K, T] {
+	return func(p *KeyedPool[K, T]) {
+		p.maxTotal = n
+	}
+}
+
+// A KeyedPool maintains a separate Pool per key, e.g. one per backend
+// host, each with its own max size. The sub-pool of a key is created on
+// the first borrow for that key.
+type KeyedPool[K comparable, T any] struct {
+	noCopy noCopy
+
+	factory     func(K) T
+	opts        []PoolOption[T]
+	idleTimeout time.Duration
+	maxTotal    int
+
+	semTotal *semaphore.Weighted // semTotal bounds borrowed items across keys
+	waiting  atomic.Int32        // waiting counts borrowers blocked on semTotal
+
+	mu     sync.RWMutex
+	pools  map[K]*keyedPool[T]
+	closed bool
+	// retired holds the counters of sub-pools already torn down
+	retired Stats
+
+	cancel     context.CancelFunc
+	background sync.WaitGroup
+}
+
+// keyedPool is the sub-pool of a key.
+type keyedPool[T any] struct {
+	pool     *Pool[T]
+	refs     atomic.Int32 // refs counts borrows in flight and borrowed items
+	lastUsed atomic.Int64 // lastUsed is when an item was last returned, in unix nanoseconds
+}
+
+// NewKeyedPool creates a new KeyedPool creating the items of a key with
+// factory.
+func NewKeyedPool[K comparable, T any](factory func(K) T, opts ...KeyedPoolOption[K, T]) *KeyedPool[K, T] {
+	pool := &KeyedPool[K, T]{
+		factory: factory,
+		pools:   make(map[K]*keyedPool[T]),
+	}
+	for _, opt := range opts {
+		opt(pool)
+	}
+	if pool.maxTotal > 0 {
+		pool.semTotal = semaphore.NewWeighted(int64(pool.maxTotal))
+	}
+	var ctx context.Context
+	ctx, pool.cancel = context.WithCancel(context.Background())
+	if pool.idleTimeout > 0 {
+		pool.background.Add(1)
+		go pool.reap(ctx)
+	}
+	return pool
+}
+
+// Borrow obtains an item for key like Pool.Borrow does.
+func (p *KeyedPool[K, T]) Borrow(ctx context.Context, key K) T {
+	item, err := p.BorrowErr(ctx, key)
+	if errors.Is(err, ErrNoFactory) || errors.Is(err, ErrFactoryPanic) {
+		panic(err)
+	}
+	return item
+}
+
+// BorrowErr obtains an item for key like Pool.BorrowErr does. Once the
+// KeyedPool is closed, ErrPoolClosed is returned.
+func (p *KeyedPool[K, T]) BorrowErr(ctx context.Context, key K) (T, error) {
+	var zero T
+	// take the global slot before the one of the key, and give them back
+	// in reverse, so borrowers never hold a key slot waiting for a
+	// global one
+	if p.semTotal != nil {
+		p.waiting.Add(1)
+		err := p.semTotal.Acquire(ctx, 1)
+		p.waiting.Add(-1)
+		if err != nil {
+			return zero, aborted(err)
+		}
+	}
+	sub, err := p.acquire(key)
+	if err == nil {
+		var item T
+		item, err = sub.pool.BorrowErr(ctx)
+		if err == nil {
+			return item, nil
+		}
+		sub.refs.Add(-1)
+	}
+	if p.semTotal != nil {
+		p.semTotal.Release(1)
+	}
+	return zero, err
+}
+
+// ReturnItem returns an item borrowed for key back to its sub-pool.
+// Returning an item for a key nothing was borrowed for is a no-op.
+func (p *KeyedPool[K, T]) ReturnItem(key K, item T) {
+	p.mu.RLock()
+	sub, ok := p.pools[key]
+	p.mu.RUnlock()
+	if !ok {
+		return
+	}
+	sub.lastUsed.Store(time.Now().UnixNano())
+	if sub.pool.giveBack(item, 1, false) != nil {
+		return
+	}
+	sub.refs.Add(-1)
+	if p.semTotal != nil {
+		p.semTotal.Release(1)
+	}
+}
+
+// Close closes the sub-pools of all keys, returning their errors joined
+// with errors.Join. Subsequent borrows fail with ErrPoolClosed, items still
+// borrowed can be returned as usual and are destroyed on return.
+func (p *KeyedPool[K, T]) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	pools := make([]*Pool[T], 0, len(p.pools))
+	for _, sub := range p.pools {
+		pools = append(pools, sub.pool)
+	}
+	p.mu.Unlock()
+
+	p.cancel()
+	p.background.Wait()
+	var errs []error
+	for _, pool := range pools {
+		if err := pool.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// acquire returns the sub-pool of key, creating it if needed, and holds a
+// reference to it so it isn't torn down while borrowing from it.
+func (p *KeyedPool[K, T]) acquire(key K) (*keyedPool[T], error) {
+	p.mu.RLock()
+	sub, ok := p.pools[key]
+	closed := p.closed
+	if ok && !closed {
+		sub.refs.Add(1)
+	}
+	p.mu.RUnlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+	if ok {
+		return sub, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	sub, ok = p.pools[key]
+	if !ok {
+		sub = &keyedPool[T]{pool: p.newPool(key)}
+		p.pools[key] = sub
+	}
+	sub.refs.Add(1)
+	return sub, nil
+}
+
+// newPool creates the sub-pool of key.
+func (p *KeyedPool[K, T]) newPool(key K) *Pool[T] {
+	opts := append([]PoolOption[T]{}, p.opts...)
+	if p.factory != nil {
+		opts = append(opts, WithFactory[T](func() T {
+			return p.factory(key)
+		}))
+	}
+	return NewPool[T](opts...)
+}
+
+// reap closes the sub-pools unused for longer than idleTimeout until the
+// KeyedPool is closed.
+func (p *KeyedPool[K, T]) reap(ctx context.Context) {
+	defer p.background.Done()
+	ticker := time.NewTicker(p.idleTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cutoff := time.Now().Add(-p.idleTimeout).UnixNano()
+		var unused []*Pool[T]
+		p.mu.Lock()
+		for key, sub := range p.pools {
+			if sub.refs.Load() == 0 && sub.lastUsed.Load() < cutoff {
+				delete(p.pools, key)
+				unused = append(unused, sub.pool)
+			}
+		}
+		p.mu.Unlock()
+		for _, pool := range unused {
+			pool.Close()
+			stats := pool.Stats()
+			p.mu.Lock()
+			p.retired.Created += stats.Created
+			p.retired.Destroyed += stats.Destroyed
+			p.mu.Unlock()
+		}
+	}
+}
+
+// Stats returns a snapshot of all sub-pools together. Created and
+// Destroyed include sub-pools already torn down, MaxSize is the limit
+// set with WithMaxTotal, 0 if there is none, and ReuseRatio covers the
+// sub-pools of the keys in use.
+func (p *KeyedPool[K, T]) Stats() Stats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	stats := p.retired
+	var hits, misses uint64
+	for _, sub := range p.pools {
+		hits += sub.pool.Hits()
+		misses += sub.pool.Misses()
+		s := sub.pool.Stats()
+		stats.Idle += s.Idle
+		stats.InUse += s.InUse
+		stats.Waiting += s.Waiting
+		stats.Created += s.Created
+		stats.Destroyed += s.Destroyed
+	}
+	stats.Waiting += int(p.waiting.Load())
+	stats.MaxSize = p.maxTotal
+	stats.ReuseRatio = reuseRatio(hits, misses)
+	return stats
+}
+
+// KeyStats returns a snapshot of the sub-pool of key, reporting false if
+// there is no sub-pool for key.
+func (p *KeyedPool[K, T]) KeyStats(key K) (Stats, bool) {
+	p.mu.RLock()
+	sub, ok := p.pools[key]
+	p.mu.RUnlock()
+	if !ok {
+		return Stats{}, false
+	}
+	return sub.pool.Stats(), true
+}
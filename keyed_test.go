@@ -0,0 +1,123 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestKeyedPool_Borrow(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should keep a separate pool per key", func(t *testing.T) {
+		itemPool := sync.NewKeyedPool[string, *Worker](
+			func(host string) *Worker {
+				return &Worker{id: len(host)}
+			},
+			sync.WithKeyOptions[string, *Worker](
+				sync.WithSize[*Worker](1),
+				sync.WithRetentionMode[*Worker](sync.Deterministic),
+			),
+		)
+		defer itemPool.Close()
+
+		worker1 := itemPool.Borrow(ctx, "a")
+		worker2 := itemPool.Borrow(ctx, "bb")
+		assert.Equal(t, 1, worker1.id)
+		assert.Equal(t, 2, worker2.id)
+
+		// the max size applies per key
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := itemPool.BorrowErr(timeout, "a")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		itemPool.ReturnItem("a", worker1)
+		itemPool.ReturnItem("bb", worker2)
+		assert.Same(t, worker1, itemPool.Borrow(ctx, "a"))
+	})
+	t.Run("should fail once closed", func(t *testing.T) {
+		itemPool := sync.NewKeyedPool[string, *Worker](func(host string) *Worker {
+			return &Worker{id: len(host)}
+		})
+		assert.NoError(t, itemPool.Close())
+		_, err := itemPool.BorrowErr(ctx, "a")
+		assert.ErrorIs(t, err, sync.ErrPoolClosed)
+	})
+}
+
+func TestKeyedPool_WithKeyIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should tear down pools of unused keys", func(t *testing.T) {
+		created := 0
+		itemPool := sync.NewKeyedPool[string, *Worker](
+			func(host string) *Worker {
+				created++
+				return &Worker{id: created}
+			},
+			sync.WithKeyIdleTimeout[string, *Worker](20*time.Millisecond),
+			sync.WithKeyOptions[string, *Worker](
+				sync.WithRetentionMode[*Worker](sync.Deterministic),
+			),
+		)
+		defer itemPool.Close()
+
+		worker := itemPool.Borrow(ctx, "a")
+		time.Sleep(50 * time.Millisecond)
+		// borrowed items keep their pool alive
+		itemPool.ReturnItem("a", worker)
+		assert.Same(t, worker, itemPool.Borrow(ctx, "a"))
+		itemPool.ReturnItem("a", worker)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 2, itemPool.Borrow(ctx, "a").id)
+	})
+}
+
+func TestKeyedPool_WithMaxTotal(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should limit borrowed items across keys", func(t *testing.T) {
+		itemPool := sync.NewKeyedPool[string, *Worker](
+			func(host string) *Worker {
+				return &Worker{id: len(host)}
+			},
+			sync.WithMaxTotal[string, *Worker](2),
+			sync.WithKeyOptions[string, *Worker](
+				sync.WithSize[*Worker](2),
+				sync.WithRetentionMode[*Worker](sync.Deterministic),
+			),
+		)
+		defer itemPool.Close()
+
+		worker1 := itemPool.Borrow(ctx, "a")
+		worker2 := itemPool.Borrow(ctx, "bb")
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := itemPool.BorrowErr(timeout, "ccc")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		stats := itemPool.Stats()
+		assert.Equal(t, 2, stats.InUse)
+		assert.Equal(t, 2, stats.MaxSize)
+		keyStats, ok := itemPool.KeyStats("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, keyStats.InUse)
+
+		// a double return frees a single slot
+		itemPool.ReturnItem("a", worker1)
+		itemPool.ReturnItem("a", worker1)
+		worker3, err := itemPool.BorrowErr(ctx, "ccc")
+		assert.NoError(t, err)
+		timeout, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = itemPool.BorrowErr(timeout, "a")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		itemPool.ReturnItem("bb", worker2)
+		itemPool.ReturnItem("ccc", worker3)
+		stats = itemPool.Stats()
+		assert.Equal(t, 0, stats.InUse)
+		assert.Equal(t, 3, stats.Idle)
+	})
+}
@@ -0,0 +1,141 @@
+package sync
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// WithLeakDetection warns about borrowed items that are not returned.
+// Items held for longer than after are reported to the WithLogger function
+// together with the stack of the call that borrowed them, and so are Item
+// handles garbage collected without being closed.
+//
+// Capturing stacks adds to the cost of every borrow, so leak detection is
+// off by default and meant for development and staging. Only items
+// tracked one by one are reported, see NewPointerPool.
+func WithLeakDetection[T any](after time.Duration) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.leakAfter = after
+	}
+}
+
+// WithDebug records when and where each item was borrowed, so that
+// DumpBorrowed can tell who is holding on to the items of the pool.
+// Like leak detection, it adds to the cost of every borrow.
+func WithDebug[T any](enabled bool) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.debug = enabled
+	}
+}
+
+// WithMaxBorrowDuration calls onExceed with every item that is still
+// borrowed d after it was handed out, e.g. to log it or to forcibly close
+// it. onExceed runs on its own goroutine and must not return the item to
+// the pool; the item stays borrowed until its holder returns it.
+//
+// As with leak detection, only items tracked one by one are watched.
+func WithMaxBorrowDuration[T any](d time.Duration, onExceed func(T)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		if onExceed == nil {
+			return
+		}
+		p.maxBorrow = d
+		p.onExceed = onExceed
+	}
+}
+
+// BorrowInfo describes an item that is currently borrowed.
+type BorrowInfo[T any] struct {
+	// Item is the borrowed item.
+	Item T
+	// BorrowedAt is when the item was borrowed.
+	BorrowedAt time.Time
+	// Held is for how long the item has been borrowed.
+	Held time.Duration
+	// Stack is the stack of the call that borrowed the item.
+	Stack string
+}
+
+// DumpBorrowed lists the items currently borrowed from the pool. Which
+// call borrowed them, when and for how long they are held is only known
+// with WithDebug or WithLeakDetection enabled. Items which can't be told
+// apart aren't listed.
+func (p *Pool[T]) DumpBorrowed() []BorrowInfo[T] {
+	var infos []BorrowInfo[T]
+	now := time.Now()
+	p.ledger.each(func(e *entry[T]) {
+		info := BorrowInfo[T]{Item: e.item}
+		if !e.borrowedAt.IsZero() {
+			info.BorrowedAt = e.borrowedAt
+			info.Held = now.Sub(e.borrowedAt)
+			info.Stack = e.borrowStack.String()
+		}
+		infos = append(infos, info)
+	})
+	return infos
+}
+
+// watch records where a borrowed item was borrowed and starts its leak
+// detection timer.
+func (p *Pool[T]) watch(e *entry[T]) {
+	if p.maxBorrow > 0 {
+		item := e.item
+		e.watchdog = time.AfterFunc(p.maxBorrow, func() {
+			p.warn("sync: item borrowed for too long", "after", p.maxBorrow)
+			p.onExceed(item)
+		})
+	}
+	if p.metrics != nil {
+		e.borrowedAt = time.Now()
+	}
+	if !p.debug && p.leakAfter <= 0 {
+		return
+	}
+	stack := captureStack(2)
+	e.borrowedAt = time.Now()
+	e.borrowStack = stack
+	if p.leakAfter > 0 {
+		e.leakTimer = time.AfterFunc(p.leakAfter, func() {
+			p.warn("sync: possible leak, borrowed item was not returned", "after", p.leakAfter, "stack", stack.String())
+		})
+	}
+}
+
+// unwatch stops the leak detection and watchdog timers of a returned item.
+func (p *Pool[T]) unwatch(e *entry[T]) {
+	if e.watchdog != nil {
+		e.watchdog.Stop()
+		e.watchdog = nil
+	}
+	if e.leakTimer != nil {
+		e.leakTimer.Stop()
+		e.leakTimer = nil
+	}
+	e.borrowedAt = time.Time{}
+	e.borrowStack = nil
+}
+
+// callStack is the program counters of a goroutine stack.
+type callStack []uintptr
+
+// captureStack records the stack of the calling goroutine, skipping the
+// given number of callers in addition to captureStack itself.
+func captureStack(skip int) callStack {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pcs)
+	return pcs[:n]
+}
+
+func (s callStack) String() string {
+	var b strings.Builder
+	frames := runtime.CallersFrames(s)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			return b.String()
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package sync_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"runtime"
+	"strings"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to write warnings to concurrently.
+type syncBuffer struct {
+	mu  gosync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLog returns a logger writing warnings to out.
+func captureLog[T any](out *syncBuffer) sync.PoolOption[T] {
+	return sync.WithLogger[T](func(msg string, keyvals ...any) {
+		fmt.Fprintln(out, append([]any{msg}, keyvals...)...)
+	})
+}
+
+func TestPool_WithLeakDetection(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should report items held for too long with their borrow site", func(t *testing.T) {
+		out := &syncBuffer{}
+		itemPool := sync.NewPool[*Worker](
+			captureLog[*Worker](out),
+			sync.WithSize[*Worker](2),
+			sync.WithLeakDetection[*Worker](20*time.Millisecond),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		returned := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(returned)
+		leaked := itemPool.Borrow(ctx)
+
+		assert.Eventually(t, func() bool {
+			return strings.Contains(out.String(), "TestPool_WithLeakDetection")
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 1, strings.Count(out.String(), "possible leak"))
+		itemPool.ReturnItem(leaked)
+	})
+	t.Run("should report item handles collected without being closed", func(t *testing.T) {
+		out := &syncBuffer{}
+		itemPool := sync.NewPool[*Worker](
+			captureLog[*Worker](out),
+			sync.WithSize[*Worker](2),
+			sync.WithLeakDetection[*Worker](time.Hour),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		_, err := itemPool.Acquire(ctx)
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			return strings.Contains(out.String(), "garbage collected without being closed")
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestPool_WithMaxBorrowDuration(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should call back with items held too long", func(t *testing.T) {
+		exceeded := make(chan *Worker, 1)
+		itemPool := sync.NewPool[*Worker](
+			sync.WithMaxBorrowDuration[*Worker](10*time.Millisecond, func(w *Worker) {
+				exceeded <- w
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		select {
+		case got := <-exceeded:
+			assert.Same(t, worker, got)
+		case <-time.After(time.Second):
+			t.Fatal("expected callback for item held too long")
+		}
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should not call back with items returned in time", func(t *testing.T) {
+		var calls atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithMaxBorrowDuration[*Worker](20*time.Millisecond, func(w *Worker) {
+				calls.Add(1)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+}
+
+func TestPool_DumpBorrowed(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should list borrowed items with their borrow site", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithDebug[*Worker](true),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker2)
+		time.Sleep(5 * time.Millisecond)
+
+		infos := itemPool.DumpBorrowed()
+		assert.Len(t, infos, 1)
+		assert.Same(t, worker1, infos[0].Item)
+		assert.GreaterOrEqual(t, infos[0].Held, 5*time.Millisecond)
+		assert.Contains(t, infos[0].Stack, "TestPool_DumpBorrowed")
+		itemPool.ReturnItem(worker1)
+		assert.Empty(t, itemPool.DumpBorrowed())
+	})
+	t.Run("should only list items without debugging", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		infos := itemPool.DumpBorrowed()
+		assert.Len(t, infos, 1)
+		assert.Empty(t, infos[0].Stack)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithLogger(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should warn about double returns and factory panics", func(t *testing.T) {
+		out := &syncBuffer{}
+		itemPool := sync.NewPool[*Worker](
+			captureLog[*Worker](out),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			panic("misconfigured")
+		})
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrFactoryPanic)
+		assert.Contains(t, out.String(), "factory panicked panic misconfigured")
+
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(worker)
+		assert.Contains(t, out.String(), "may have been returned twice")
+	})
+	t.Run("should warn about items still borrowed on close", func(t *testing.T) {
+		out := &syncBuffer{}
+		itemPool := sync.NewPool[*Worker](
+			captureLog[*Worker](out),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.NoError(t, itemPool.Close())
+		assert.Contains(t, out.String(), "borrowed 1")
+		itemPool.ReturnItem(worker)
+	})
+}
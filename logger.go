@@ -0,0 +1,24 @@
+package sync
+
+// WithLogger sets a function the pool reports warnings to, such as
+// possible leaks, double returns or factory panics. The message is
+// followed by alternating keys and values describing the event, which maps
+// directly onto structured loggers:
+//
+//	sync.WithLogger[*Conn](func(msg string, keyvals ...any) {
+//		slog.Warn(msg, keyvals...)
+//	})
+//
+// Without a logger, warnings are dropped. Panics are never swallowed.
+func WithLogger[T any](fn func(msg string, keyvals ...any)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.logger = fn
+	}
+}
+
+// warn reports a warning to the logger, if one is set.
+func (p *Pool[T]) warn(msg string, keyvals ...any) {
+	if p.logger != nil {
+		p.logger(msg, keyvals...)
+	}
+}
@@ -0,0 +1,70 @@
+package sync
+
+import "time"
+
+// MetricKind tells what happened in a MetricEvent.
+type MetricKind int
+
+const (
+	// Borrowed is reported when an item is handed out.
+	Borrowed MetricKind = iota
+	// Returned is reported when a borrowed item is returned. Its duration
+	// is how long the item was borrowed, if known.
+	Returned
+	// Created is reported when the factory created an item. Its duration
+	// is how long the factory took.
+	Created
+	// Destroyed is reported when an item leaves the pool for good.
+	Destroyed
+	// WaitStarted is reported when a borrower starts waiting on a full
+	// pool.
+	WaitStarted
+	// WaitEnded is reported when a borrower stops waiting, whether it got
+	// a slot or not. Its duration is how long it waited.
+	WaitEnded
+)
+
+func (k MetricKind) String() string {
+	switch k {
+	case Borrowed:
+		return "borrowed"
+	case Returned:
+		return "returned"
+	case Created:
+		return "created"
+	case Destroyed:
+		return "destroyed"
+	case WaitStarted:
+		return "wait_started"
+	case WaitEnded:
+		return "wait_ended"
+	}
+	return "unknown"
+}
+
+// MetricEvent is reported to the WithMetricsHook function.
+type MetricEvent struct {
+	Kind MetricKind
+	// Duration is set for the kinds which document it, zero otherwise.
+	Duration time.Duration
+}
+
+// WithMetricsHook sets a function receiving an event for every change in
+// the pool, e.g. to forward them to StatsD or OpenTelemetry. fn is called
+// synchronously on the path of the event and must be fast; Borrowed and
+// Returned events are reported for every borrow.
+//
+// Returned events only carry the borrow duration for items tracked one by
+// one, see NewPointerPool.
+func WithMetricsHook[T any](fn func(event MetricEvent)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.metrics = fn
+	}
+}
+
+// emit reports an event to the metrics hook, if one is set.
+func (p *Pool[T]) emit(kind MetricKind, d time.Duration) {
+	if p.metrics != nil {
+		p.metrics(MetricEvent{Kind: kind, Duration: d})
+	}
+}
@@ -0,0 +1,54 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+func TestPool_WithMetricsHook(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should report pool events", func(t *testing.T) {
+		var mu gosync.Mutex
+		var events []sync.MetricEvent
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithMetricsHook[*Worker](func(event sync.MetricEvent) {
+				mu.Lock()
+				events = append(events, event)
+				mu.Unlock()
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			itemPool.ReturnItem(worker)
+		}()
+		worker = itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		assert.NoError(t, itemPool.Close())
+
+		mu.Lock()
+		defer mu.Unlock()
+		var kinds []sync.MetricKind
+		for _, event := range events {
+			kinds = append(kinds, event.Kind)
+		}
+		assert.Equal(t, []sync.MetricKind{
+			sync.Created, sync.Borrowed,
+			sync.WaitStarted, sync.Returned, sync.WaitEnded, sync.Borrowed,
+			sync.Returned, sync.Destroyed,
+		}, kinds)
+		assert.GreaterOrEqual(t, events[3].Duration, 20*time.Millisecond)
+		assert.GreaterOrEqual(t, events[4].Duration, 20*time.Millisecond)
+		assert.Equal(t, "wait_ended", events[4].Kind.String())
+	})
+}
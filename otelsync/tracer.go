@@ -0,0 +1,52 @@
+// Package otelsync traces go-sync pool borrows with OpenTelemetry.
+//
+// It lives in its own module so that the core pool does not depend on
+// OpenTelemetry.
+package otelsync
+
+import (
+	"context"
+
+	"github.com/kushsharma/go-sync"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanName is the name of the spans started around borrows.
+const SpanName = "pool.borrow.wait"
+
+// Tracer is a sync.BorrowTracer starting a span for every borrow, for use
+// with sync.WithTracer.
+type Tracer struct {
+	tracer trace.Tracer
+	pool   attribute.KeyValue
+}
+
+var _ sync.BorrowTracer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer starting spans with tracer, attributing them
+// to the pool with the given name.
+func NewTracer(name string, tracer trace.Tracer) *Tracer {
+	return &Tracer{
+		tracer: tracer,
+		pool:   attribute.String("pool.name", name),
+	}
+}
+
+// StartBorrow starts a span which ends once the borrow is done, recording
+// how long it waited for a slot and whether its item was created.
+func (t *Tracer) StartBorrow(ctx context.Context) (context.Context, func(sync.BorrowTrace)) {
+	ctx, span := t.tracer.Start(ctx, SpanName, trace.WithAttributes(t.pool))
+	return ctx, func(info sync.BorrowTrace) {
+		span.SetAttributes(
+			attribute.Int64("pool.wait_ms", info.Waited.Milliseconds()),
+			attribute.Bool("pool.created", info.Created),
+		)
+		if info.Err != nil {
+			span.RecordError(info.Err)
+			span.SetStatus(codes.Error, info.Err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,44 @@
+package otelsync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/kushsharma/go-sync/otelsync"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+type Worker struct {
+	id int
+}
+
+func TestTracer(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should record a span per borrow", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		itemPool := sync.NewPool[*Worker](
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithTracer[*Worker](otelsync.NewTracer("workers", provider.Tracer("test"))),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: 1}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		worker = itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+
+		spans := recorder.Ended()
+		assert.Len(t, spans, 2)
+		for i, created := range []bool{true, false} {
+			assert.Equal(t, otelsync.SpanName, spans[i].Name())
+			attrs := spans[i].Attributes()
+			assert.Contains(t, attrs, attribute.String("pool.name", "workers"))
+			assert.Contains(t, attrs, attribute.Bool("pool.created", created))
+		}
+	})
+}
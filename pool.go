@@ -2,13 +2,20 @@ package sync
 
 import (
 	"context"
-	"runtime"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"golang.org/x/sync/semaphore"
 )
 
+// maxCapacity is the largest max size a bounded pool can be resized to.
+const maxCapacity = math.MaxInt32
+
 // PoolOption configures the Pool
 type PoolOption[T any] func(*Pool[T])
 
@@ -19,27 +26,423 @@ func WithBootstrapItems[T any](c int) PoolOption[T] {
 	}
 }
 
-// WithSize limits the number of items in the pool
+// WithLazyBootstrap creates the bootstrap items in the background once a
+// factory is set, instead of blocking until all of them are created. The
+// pool can be used right away and counts the items as they become ready;
+// bootstrap errors are reported to the WithLogger function.
+func WithLazyBootstrap[T any]() PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.lazy = true
+	}
+}
+
+// WithSize limits the number of items in the pool. The limit must be
+// between 1 and math.MaxInt32, NewPool panics otherwise; use WithUnlimited
+// for a pool without a limit.
 func WithSize[T any](l int) PoolOption[T] {
 	return func(p *Pool[T]) {
+		if l < 1 {
+			p.invalid("size must be at least 1, got %d, use WithUnlimited for an unbounded pool", l)
+			return
+		}
 		p.max = l
+		p.sized = true
+	}
+}
+
+// WithUnlimited lets the pool grow without a limit, even with bootstrap
+// items. It undoes an earlier WithSize. Without either option the pool is
+// unlimited as well, unless it has bootstrap items: their number is its
+// max size then.
+func WithUnlimited[T any]() PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.max = 0
+		p.sized = true
+	}
+}
+
+// WithContext sets the context the background goroutines of the pool,
+// such as the min idle replenisher, derive from. Cancelling it stops them
+// like Close does, but leaves the pool open for borrows.
+func WithContext[T any](ctx context.Context) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.ctx = ctx
+	}
+}
+
+// WithFactory sets the function generating items at construction time, so
+// the pool returned by NewPool is ready to use and already holds its
+// bootstrap items. This is the recommended way to wire a factory,
+// SetFactory remains available to replace it later on.
+func WithFactory[T any](fn func() T) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.factory.store(func(context.Context) (T, error) {
+			return fn(), nil
+		})
+	}
+}
+
+// WithFactoryRetry retries creating an item when the factory fails, up to
+// attempts more times, waiting backoff(attempt) before retry attempt 1, 2
+// and so on; a nil backoff retries right away. Borrowers keep their slot
+// while retrying, and give up early with the context error once their
+// context is done. Panics are not retried.
+func WithFactoryRetry[T any](attempts int, backoff func(attempt int) time.Duration) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.retries = attempts
+		p.backoff = backoff
+	}
+}
+
+// WithBulkFactory sets a function generating n items at once, for items
+// which are cheaper to make in bulk, e.g. by slicing one big backing array.
+// The pool uses it to create bootstrap items, Warmup items and the idle
+// items kept by WithMinIdle. Borrowers still create missing items one at
+// a time with the factory, or with fn(1) if there is none. fn may return
+// fewer than n items, extra ones are ignored.
+func WithBulkFactory[T any](fn func(n int) []T) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.bulkFactory = fn
+	}
+}
+
+// WithMaxWaiters limits the number of borrowers blocked on a full pool
+// to n. Once n borrowers are waiting, further borrows fail right away with
+// ErrTooManyWaiters instead of blocking, shedding load rather than piling
+// up goroutines.
+func WithMaxWaiters[T any](n int) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.maxWaiters = n
+	}
+}
+
+// WithBorrowFailFast sets a predicate consulted with a snapshot of the
+// pool whenever a borrow would block on a full pool. If it reports true,
+// e.g. because every item has been borrowed for long with no returns in
+// sight, the borrow fails right away with ErrWouldBlock instead of
+// blocking, an admission control policy based on live Stats.
+func WithBorrowFailFast[T any](when func(stats Stats) bool) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.failFast = when
+	}
+}
+
+// WithOverflow turns the size of the pool into a soft limit. Once it is
+// reached, Borrow hands out a freshly created item instead of blocking, and
+// that item is destroyed when it is returned instead of being kept, so the
+// pool never holds more than its size once the burst is over. Borrows of
+// more than one slot, weighted ones and batches, still wait for slots. It
+// has no effect on pools without a max size.
+func WithOverflow[T any](allow bool) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.overflow = allow
+	}
+}
+
+// WithMaxIdle limits the number of idle items kept in the pool. Items
+// returned while n items are already idle are destroyed. It can't exceed
+// the max size of the pool.
+func WithMaxIdle[T any](n int) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.maxIdle = n
+	}
+}
+
+// WithMaxLifetime limits how long an item is reused after its creation.
+// Borrow destroys idle items older than d instead of handing them out.
+//
+// Creation times are kept across borrows only for items that can be told
+// apart, see NewPointerPool. Other items are considered created when they
+// are returned.
+func WithMaxLifetime[T any](d time.Duration) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.maxLifetime = d
+	}
+}
+
+// WithMaxUses recycles items after n borrows, the way connection pools
+// retire a connection after a fixed number of requests. An item borrowed
+// n times is destroyed when it is returned, and the next borrow creates a
+// replacement.
+//
+// As with WithMaxLifetime, uses are only counted across borrows for items
+// that can be told apart; other items count as used once whenever they are
+// returned.
+func WithMaxUses[T any](n int) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.maxUses = n
+	}
+}
+
+// WithMaxIdleTime limits how long an item may sit idle in the pool.
+// Borrow destroys idle items unused for longer than d instead of handing
+// them out. With Deterministic retention, a background goroutine also
+// evicts them as they expire until the pool is closed; Ephemeral pools
+// can't enumerate their idle items and only evict them on borrow.
+func WithMaxIdleTime[T any](d time.Duration) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.maxIdleTime = d
+	}
+}
+
+// WithResetFunc sets a function that cleans an item when it is returned,
+// before it is made available to the next borrower.
+func WithResetFunc[T any](reset func(T)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.reset.store(reset)
+	}
+}
+
+// WithOnBorrow sets a function that is called on an item each time it
+// leaves the pool, right before Borrow hands it to the caller. For freshly
+// created items it runs after the factory.
+//
+// If fn panics, the item is destroyed and the slot held for it is released
+// before the panic continues, so the pool does not lose capacity.
+func WithOnBorrow[T any](fn func(T)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.onBorrow.store(fn)
+	}
+}
+
+// WithDestroyFunc sets a function that releases the resources held by an
+// item when the pool explicitly evicts it, e.g. when Close or Purge drains
+// idle items or a hook rejects an item.
+//
+// Without a destroy function, items implementing io.Closer are closed
+// instead, see WithCloseErrorHandler.
+//
+// Idle items that the garbage collector drops from the underlying
+// sync.Pool leave silently and are not passed to fn.
+func WithDestroyFunc[T any](fn func(T)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.destroyFn = fn
+	}
+}
+
+// WithCloseErrorHandler sets a function receiving the errors of closing
+// evicted items. Unless a destroy function is set, items implementing
+// io.Closer are closed when the pool evicts them.
+func WithCloseErrorHandler[T any](fn func(item T, err error)) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.onCloseError = fn
+	}
+}
+
+// WithValidateOnBorrow sets a function that checks whether an idle item is
+// still usable before Borrow hands it out. Items failing the check are
+// destroyed and the next idle item is tried, up to the max size of the pool.
+// If no idle item passes, a new one is created with the factory; freshly
+// created items are not validated.
+func WithValidateOnBorrow[T any](fn func(T) bool) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.validateOnBorrow.store(fn)
+	}
+}
+
+// WithRefreshFunc sets a function that rejuvenates an idle item in place,
+// e.g. by reconnecting it, when Borrow finds the item past its lifetime,
+// idle for too long, stale after a Purge or failing WithValidateOnBorrow.
+// A refreshed item counts as created anew and is handed out without being
+// validated again. If fn fails, the item is destroyed as without a refresh
+// function. Items which can't be told apart aren't refreshed in place.
+func WithRefreshFunc[T any](fn func(T) error) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.refresh = fn
+	}
+}
+
+// WithValidateOnReturn sets a function that checks whether a returned item
+// is still healthy. Items failing the check are destroyed instead of being
+// put back for the next borrower. The check runs before the reset function.
+func WithValidateOnReturn[T any](fn func(T) bool) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.validateOnReturn.store(fn)
+	}
+}
+
+// ReturnAction decides what happens to a returned item.
+type ReturnAction int
+
+const (
+	// Reset runs the reset function on the item and puts it back for the
+	// next borrower. It is what happens without a return policy.
+	Reset ReturnAction = iota
+	// Keep puts the item back for the next borrower as is, skipping the
+	// reset function.
+	Keep
+	// Discard destroys the item instead of putting it back.
+	Discard
+)
+
+// WithReturnPolicy sets a function deciding for each returned item whether
+// it is kept, reset or discarded, e.g. discarding connections which
+// reported an error. It runs after the return validation, items failing
+// it are destroyed without asking the policy. Items are still destroyed
+// when the pool is closed, they are from an older Generation or have been
+// borrowed WithMaxUses times, whatever the policy decides.
+func WithReturnPolicy[T any](fn func(T) ReturnAction) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.returnPolicy.store(fn)
+	}
+}
+
+// RetentionMode decides how a Pool holds on to its idle items.
+type RetentionMode int
+
+const (
+	// Ephemeral keeps idle items in a sync.Pool, which lets the garbage
+	// collector drop them at any time. Best for cheap items.
+	Ephemeral RetentionMode = iota
+	// Deterministic keeps idle items until the pool itself evicts them.
+	// Best for expensive items such as connections.
+	Deterministic
+)
+
+// WithRetentionMode sets how idle items are held. It defaults to Ephemeral
+// for pointer, map and channel items and to Deterministic for other value
+// types like structs, arrays and slices, whose count is then kept by the
+// pool alone without relying on finalizers.
+func WithRetentionMode[T any](mode RetentionMode) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.retention = mode
+		p.retentionSet = true
 	}
 }
 
-// NewPool creates a new Pool.
+// Ordering decides which idle item a Pool hands out next.
+type Ordering int
+
+const (
+	// LIFO hands out the most recently returned item first, keeping a few
+	// items hot. It is the default.
+	LIFO Ordering = iota
+	// FIFO hands out the least recently returned item first, rotating
+	// through all items so that they stay warm and age evenly.
+	FIFO
+)
+
+// WithOrdering sets in which order idle items are handed out. FIFO
+// ordering implies Deterministic retention.
+func WithOrdering[T any](ordering Ordering) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.ordering = ordering
+	}
+}
+
+// NewPool creates a new Pool. It panics if the options are invalid, see
+// NewPoolErr.
 func NewPool[T any](opts ...PoolOption[T]) *Pool[T] {
-	pool := &Pool[T]{}
+	pool, err := NewPoolErr(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return pool
+}
+
+// NewPointerPool creates a new Pool of pointers to T, the kind of items
+// every feature of a Pool works with: only items with an identity of their
+// own, i.e. pointers, maps and channels, can be told apart, so that double
+// returns are detected and borrowed items tracked one by one. Features
+// which follow an item across borrows, such as lifetimes, refreshing, leak
+// detection or Purge, only see such items. Unless the options set a
+// factory, items are allocated with new(T).
+func NewPointerPool[T any](opts ...PoolOption[*T]) *Pool[*T] {
+	opts = append([]PoolOption[*T]{WithFactory[*T](func() *T {
+		return new(T)
+	})}, opts...)
+	return NewPool[*T](opts...)
+}
+
+// Derive creates a new Pool configured like p, with opts applied on top of
+// the options p was created with. The factory and hooks set on p after its
+// creation carry over as well. The new pool shares no state with p: it has
+// its own slots, idle items and counters. A Store set with WithStore
+// belongs to p and is not carried over: the new pool keeps its idle items
+// in the store of its RetentionMode, unless opts set a new Store. Like
+// NewPool, Derive panics if the options are invalid.
+func (p *Pool[T]) Derive(opts ...PoolOption[T]) *Pool[T] {
+	inherit := func(d *Pool[T]) {
+		d.custom = nil
+		d.factory.store(p.factory.load())
+		d.reset.store(p.reset.load())
+		d.onBorrow.store(p.onBorrow.load())
+		d.validateOnBorrow.store(p.validateOnBorrow.load())
+		d.validateOnReturn.store(p.validateOnReturn.load())
+		d.returnPolicy.store(p.returnPolicy.load())
+	}
+	derived := append(append([]PoolOption[T]{}, p.opts...), inherit)
+	return NewPool[T](append(derived, opts...)...)
+}
+
+// NewPoolErr creates a new Pool like NewPool, but returns an error wrapping
+// ErrInvalidConfig if the options are invalid or inconsistent, e.g. when
+// more items are bootstrapped or kept idle than the max size allows.
+func NewPoolErr[T any](opts ...PoolOption[T]) (*Pool[T], error) {
+	pool := &Pool[T]{opts: append([]PoolOption[T](nil), opts...)}
 	for _, opt := range opts {
 		opt(pool)
 	}
-	if pool.max < pool.initial {
+	pool.validate()
+	if err := errors.Join(pool.configErrs...); err != nil {
+		return nil, err
+	}
+	if pool.smoothing == 0 {
+		pool.smoothing = defaultWaitSmoothing
+	}
+	if !pool.sized && pool.max < pool.initial {
+		// without a max size, the bootstrap items are the max
 		pool.max = pool.initial
 	}
 	if pool.max > 0 {
-		pool.semMax = semaphore.NewWeighted(int64(pool.max))
+		// hold back all but max permits, so Resize can hand them out later
+		pool.semMax = semaphore.NewWeighted(maxCapacity)
+		pool.semMax.TryAcquire(maxCapacity - int64(pool.max))
+		pool.size.Store(int32(pool.max))
+		if pool.shards > 1 {
+			pool.slots = newSlotShards(pool.shards)
+		}
+		pool.acquire, pool.release = pool.acquireBounded, pool.releaseBounded
+	} else {
+		pool.acquire, pool.release = pool.acquireUnbounded, pool.releaseUnbounded
+	}
+	if pool.ordering == FIFO || pool.eviction != EvictReturned || pool.minReuse > 0 ||
+		(!pool.retentionSet && isValueType[T]()) {
+		pool.retention = Deterministic
+	}
+	pool.ledger.init(pool.shards)
+	switch {
+	case pool.custom != nil:
+		pool.idle = newCustomStore[T](pool.custom, func(item T) *entry[T] {
+			e := newEntry(item)
+			e.generation = pool.generation.Load()
+			e.idleSince = e.createdAt
+			e.uses = 1
+			return e
+		}, pool.dropped)
+	case pool.retention == Deterministic:
+		if pool.shards > 1 {
+			pool.idle = newShardedStore[T](pool.shards, pool.ordering)
+		} else {
+			pool.idle = newSliceStore[T](pool.ordering)
+		}
+	default:
+		pool.idle = newSyncPoolStore[T](pool.dropped)
+	}
+	if pool.ctx == nil {
+		pool.ctx = context.Background()
+	}
+	pool.ctx, pool.cancel = context.WithCancel(pool.ctx)
+	pool.lowIdle = make(chan struct{}, 1)
+	pool.idleReady = make(chan struct{}, 1)
+	pool.batchTurn = make(chan struct{}, 1)
+	pool.allReturned = make(chan struct{}, 1)
+	if factory := pool.factory.load(); factory != nil || pool.bulkFactory != nil {
+		pool.setFactory(pool.ctx, factory)
 	}
 
-	return pool
+	pool.self = pool
+	return pool, nil
 }
 
 // A Pool is a set of temporary objects that may be individually saved and
@@ -47,7 +450,8 @@ func NewPool[T any](opts ...PoolOption[T]) *Pool[T] {
 //
 // Any item stored in the Pool may be removed automatically at any time without
 // notification. If the Pool holds the only reference when this happens, the
-// item might be deallocated.
+// item might be deallocated. Use the Deterministic retention mode to keep idle
+// items until the pool evicts them, it is the default for value types.
 //
 // A Pool is safe for use by multiple goroutines simultaneously.
 //
@@ -66,76 +470,1472 @@ func NewPool[T any](opts ...PoolOption[T]) *Pool[T] {
 // that scenario. It is more efficient to have such objects implement their own
 // free list.
 //
-// A Pool must not be copied after first use.
+// The zero value of a Pool is not usable, create pools with NewPool:
+// borrowing from the zero value fails with ErrPoolNotCreated. A Pool must
+// not be copied after first use. go vet reports copies, and borrowing from
+// a copy fails with ErrPoolCopied.
 type Pool[T any] struct {
 	noCopy noCopy
+	self   *Pool[T] // self is the pool created by NewPoolErr, to detect copies
+
+	configErrs []error         // configErrs are the errors of invalid options
+	opts       []PoolOption[T] // opts are the options the pool was created with, for Derive
 
 	initial int
+	lazy    bool
 	max     int
+	sized   bool // sized is set by WithSize and WithUnlimited
+	maxIdle int
+	minIdle int
+
+	maxLifetime time.Duration
+	maxIdleTime time.Duration
+	maxUses     int
+	leakAfter   time.Duration
+	debug       bool
+	maxBorrow   time.Duration
+	maxWaiters  int
+	failFast    func(Stats) bool
+	overflow    bool
+	onExceed    func(T)
+	guard       *reentrancyGuard
+	breaker     *circuitBreaker
+	saturation  *saturation
+	tier        *overflowTier[T]
+	smoothing   float64
+	waitBounds  []time.Duration
+	waitCounts  []atomic.Uint64 // waitCounts has a bucket per wait bound and one for longer waits
+
+	retention    RetentionMode
+	retentionSet bool
+	shards       int
+	ordering     Ordering
+	eviction     EvictionPolicy
+	minReuse     float64
+	idle         store[T]
+	custom       Store[T] // custom is the store set with WithStore
+	ledger       ledger[T]
+	semMax       *semaphore.Weighted
+	slots        *slotShards // slots caches free slots of a bounded pool with shards
+	// acquire and release reserve and give up slots for items. They are
+	// chosen once the size is known, so that unbounded pools never touch
+	// the semaphore.
+	acquire func(ctx context.Context, n int64, prio int) error
+	release func(n int64)
+
+	factory      hook[func(context.Context) (T, error)]
+	bulkFactory  func(int) []T
+	retries      int
+	backoff      func(int) time.Duration
+	reset        hook[func(T)]
+	onBorrow     hook[func(T)]
+	destroyFn    func(T)
+	onCloseError func(T, error)
+	logger       func(string, ...any)
+	metrics      func(MetricEvent)
+	tracer       BorrowTracer
+	createTiming bool
 
-	syncPool sync.Pool
-	semMax   *semaphore.Weighted
+	validateOnBorrow hook[func(T) bool]
+	validateOnReturn hook[func(T) bool]
+	returnPolicy     hook[func(T) ReturnAction]
+	refresh          func(T) error
+	sizer            func(T) int64
+	maxBytes         int64
+
+	count     atomic.Int32 // count keeps track of how many items are in the pool
+	live      atomic.Int32 // live is count without the items dropped by the collector
+	size      atomic.Int32 // size is the current max size, 0 if unbounded
+	inUse     atomic.Int32 // inUse keeps track of how many items are borrowed
+	idleCount atomic.Int32 // idleCount keeps track of how many items are idle
+	waiting   atomic.Int32 // waiting keeps track of how many borrowers are blocked
+	peakInUse atomic.Int32 // peakInUse is the high-water mark of inUse
+	avgWait   atomic.Int64 // avgWait is the moving average of waits for slots, in nanoseconds
+	created   atomic.Uint64
+	destroyed atomic.Uint64
+	retained  atomic.Int64  // retained is the bytes of idle items, measured by sizer
+	hits      atomic.Uint64 // hits counts borrows served with an idle item
+	misses    atomic.Uint64 // misses counts borrows calling the factory
+	// createNanos and createCount sum up the factory runs timed with
+	// WithCreateTiming
+	createNanos atomic.Int64
+	createCount atomic.Uint64
+	// overflowed counts borrowed overflow items without identity
+	overflowed atomic.Int32
+	extra      atomic.Int32 // extra counts the overflow and overflow tier items
+	closed     atomic.Bool
+
+	// generation is bumped by Purge and Resize, items created before are stale
+	generation atomic.Uint64
+
+	// ctx lives as long as the pool and stops its background goroutines
+	ctx         context.Context
+	cancel      context.CancelFunc
+	background  sync.WaitGroup
+	startOnce   sync.Once
+	lowIdle     chan struct{} // lowIdle wakes up the replenisher
+	idleReady   chan struct{} // idleReady wakes up a BorrowSoft waiting for an idle item
+	allReturned chan struct{} // allReturned wakes up Shutdown once no item is borrowed
+
+	fair      bool
+	batchTurn chan struct{} // batchTurn lets one batch at a time acquire slots
+
+	resizeMu sync.Mutex
+	owed     atomic.Int64 // owed is the number of permits a shrink still has to take back
+
+	queueMu  sync.Mutex
+	queue    waitQueue    // queue holds borrowers waiting by priority
+	queueSeq uint64       // queueSeq orders waiters of the same priority
+	queued   atomic.Int32 // queued is the length of queue
+
+	idleMu      sync.Mutex
+	idleWaiters []chan struct{} // idleWaiters are the channels returned by Idle
+	idleWaiting atomic.Int32    // idleWaiting is the length of idleWaiters
+}
+
+// validate records the options which are inconsistent with each other.
+func (p *Pool[T]) validate() {
+	if p.initial < 0 {
+		p.invalid("bootstrap items must not be negative, got %d", p.initial)
+	}
+	if p.max > maxCapacity {
+		p.invalid("size must be at most %d, got %d", maxCapacity, p.max)
+	}
+	if p.initial > maxCapacity {
+		p.invalid("bootstrap items must be at most %d, got %d", maxCapacity, p.initial)
+	}
+	if p.max > 0 && p.initial > p.max {
+		p.invalid("bootstrap items (%d) exceed the size (%d)", p.initial, p.max)
+	}
+	if p.maxUses < 0 {
+		p.invalid("max uses must not be negative, got %d", p.maxUses)
+	}
+	if p.maxBytes < 0 {
+		p.invalid("max bytes must not be negative, got %d", p.maxBytes)
+	}
+	if p.maxBytes > 0 && p.sizer == nil {
+		p.invalid("max bytes needs a sizer, set with WithSizer")
+	}
+	if p.maxIdle < 0 {
+		p.invalid("max idle must not be negative, got %d", p.maxIdle)
+	}
+	if p.minIdle < 0 {
+		p.invalid("min idle must not be negative, got %d", p.minIdle)
+	}
+	if p.max > 0 && p.maxIdle > p.max {
+		p.invalid("max idle (%d) exceeds the size (%d)", p.maxIdle, p.max)
+	}
+	if p.max > 0 && p.minIdle > p.max {
+		p.invalid("min idle (%d) exceeds the size (%d)", p.minIdle, p.max)
+	}
+	if p.maxIdle > 0 && p.minIdle > p.maxIdle {
+		p.invalid("min idle (%d) exceeds max idle (%d)", p.minIdle, p.maxIdle)
+	}
+	if p.maxLifetime < 0 || p.maxIdleTime < 0 {
+		p.invalid("max lifetime and max idle time must not be negative")
+	}
+	if p.tier != nil && p.max == 0 && p.initial == 0 {
+		p.invalid("overflow tier needs a size, set with WithSize")
+	}
+	if p.tier != nil && p.overflow {
+		p.invalid("an overflow tier excludes WithOverflow")
+	}
+	if p.retries < 0 {
+		p.invalid("factory retries must not be negative, got %d", p.retries)
+	}
+	if p.maxWaiters < 0 {
+		p.invalid("max waiters must not be negative, got %d", p.maxWaiters)
+	}
+}
 
-	count atomic.Int32 // count keeps track of how many items are in the pool
+// invalid records an invalid option.
+func (p *Pool[T]) invalid(format string, args ...any) {
+	p.configErrs = append(p.configErrs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
 }
 
 // SetFactory specifies a function to generate an item when Borrow is called.
+// It may be called again to replace the factory, also while the pool is in
+// use; borrows already in progress may still use the previous factory.
+// Bootstrap items are only created the first time a factory is set.
 //
-// Factory should only return pointer types
+// Factory should return pointer types, see NewPointerPool.
 func (p *Pool[T]) SetFactory(ctx context.Context, factory func() any) {
-	p.syncPool.New = func() any {
-		newItem := factory()
+	p.setFactory(ctx, func(context.Context) (T, error) {
+		return factory().(T), nil
+	})
+}
 
-		p.count.Add(1)
-		runtime.SetFinalizer(newItem, func(newItem any) {
-			p.count.Add(-1)
-		})
-		return newItem
+// SetFactoryErr specifies a function to generate an item when Borrow is
+// called, for items whose construction can fail. When the factory returns
+// an error during a borrow, the slot is released again and the error is
+// returned from BorrowErr.
+//
+// Bootstrap items are created with the same factory; bootstrapping stops
+// at the first error.
+//
+// Factory should return pointer types, see NewPointerPool.
+func (p *Pool[T]) SetFactoryErr(ctx context.Context, factory func() (any, error)) {
+	p.SetFactoryCtx(ctx, func(context.Context) (any, error) {
+		return factory()
+	})
+}
+
+// SetFactoryCtx specifies a function to generate an item when Borrow is
+// called, like SetFactoryErr, for factories which should give up when the
+// borrower does. The factory receives the context passed to Borrow, so
+// cancelling a borrow stops an item that is still being created, e.g. a
+// connection being dialed; the slot is released again and the context
+// error returned. Its values are seen by the factory as well, so items can
+// be tagged with e.g. the tenant or trace ID of the borrow creating them.
+// Bootstrap items are created with ctx, and items created in the
+// background with the context of WithContext.
+//
+// Factory should return pointer types, see NewPointerPool.
+func (p *Pool[T]) SetFactoryCtx(ctx context.Context, factory func(ctx context.Context) (any, error)) {
+	p.setFactory(ctx, func(ctx context.Context) (T, error) {
+		newItem, err := factory(ctx)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return newItem.(T), nil
+	})
+}
+
+// SetTypedFactory specifies a function to generate an item when Borrow is
+// called. Unlike SetFactory, the factory returns T directly so a mismatched
+// item type is caught at compile time instead of panicking inside Borrow.
+func (p *Pool[T]) SetTypedFactory(ctx context.Context, factory func() T) {
+	p.setFactory(ctx, func(context.Context) (T, error) {
+		return factory(), nil
+	})
+}
+
+func (p *Pool[T]) setFactory(ctx context.Context, factory func(context.Context) (T, error)) {
+	p.factory.store(factory)
+	p.startOnce.Do(func() {
+		if p.lazy {
+			p.background.Add(1)
+			go p.bootstrapLazily()
+		} else if err := p.bootstrap(ctx); err != nil {
+			p.warn("sync: bootstrapping items failed", "err", err)
+		}
+		p.startBackground()
+	})
+}
+
+// bootstrapLazily creates the initial number of items in the background,
+// making each available as soon as it is ready.
+func (p *Pool[T]) bootstrapLazily() {
+	defer p.background.Done()
+	err := p.bootstrap(p.ctx)
+	if err != nil && p.ctx.Err() == nil {
+		p.warn("sync: bootstrapping items failed", "err", err)
+	}
+}
+
+// bootstrap creates the initial number of items. They are made directly
+// with the factory and put idle without taking slots, so bootstrapping
+// never waits for borrowers, and borrowers never wait for it.
+func (p *Pool[T]) bootstrap(ctx context.Context) error {
+	n := p.initial
+	if size := p.size.Load(); size > 0 && n > int(size-p.primaryCount()) {
+		n = int(size - p.primaryCount())
+	}
+	entries, err := p.createMany(ctx, n)
+	for _, e := range entries {
+		p.putIdle(e)
+	}
+	return err
+}
+
+// Warmup creates up to n new items and keeps them idle, e.g. ahead of an
+// expected traffic spike. It stops early once the pool reaches its max size
+// or max idle items, and returns the context error if ctx is done before all
+// items are created. It is safe to call while the pool is in use.
+func (p *Pool[T]) Warmup(ctx context.Context, n int) error {
+	return p.WarmupConcurrent(ctx, n, 1)
+}
+
+// WarmupConcurrent warms up the pool like Warmup, creating up to
+// parallelism items at a time for items which are slow to create, while
+// not hammering the backend they come from with n calls at once. Items
+// created before ctx is done or the factory fails are kept, the error of
+// the first failure is returned.
+func (p *Pool[T]) WarmupConcurrent(ctx context.Context, n, parallelism int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	if p.maxIdle > 0 && n > p.maxIdle-int(p.idleCount.Load()) {
+		n = p.maxIdle - int(p.idleCount.Load())
+	}
+	// hold slots while creating so the pool can't outgrow its max size
+	n = p.reserve(n)
+	defer p.release(int64(n))
+	if parallelism > n {
+		parallelism = n
+	}
+	if parallelism <= 1 {
+		return p.warmup(ctx, n)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var failed sync.Once
+	var err error
+	for i := 0; i < parallelism; i++ {
+		share := n / parallelism
+		if i < n%parallelism {
+			share++
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if e := p.warmup(ctx, share); e != nil {
+				failed.Do(func() {
+					// stop the others, their errors only follow from this one
+					err = e
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	return err
+}
+
+// warmup creates n idle items for slots already held.
+func (p *Pool[T]) warmup(ctx context.Context, n int) error {
+	entries, err := p.createMany(ctx, n)
+	for _, e := range entries {
+		p.putIdle(e)
+	}
+	return err
+}
+
+// reserve takes up to n free slots without waiting, for creating idle
+// items without letting the pool outgrow its max size, and returns how
+// many it took.
+func (p *Pool[T]) reserve(n int) int {
+	if p.semMax == nil || n <= 0 {
+		return n
+	}
+	taken := 0
+	for taken < n && p.primaryCount()+int32(taken) < p.size.Load() && p.takeSlots(1) {
+		taken++
+	}
+	return taken
+}
+
+// create builds a new item with the factory and starts tracking it.
+func (p *Pool[T]) create(ctx context.Context) (*entry[T], error) {
+	factory := p.factory.load()
+	if factory == nil {
+		if p.bulkFactory != nil {
+			entries, err := p.createBulk(1)
+			if err == nil && len(entries) == 0 {
+				err = errors.New("sync: bulk factory returned no items")
+			}
+			if err != nil {
+				return nil, err
+			}
+			return entries[0], nil
+		}
+		return nil, ErrNoFactory
+	}
+	var start time.Time
+	if p.metrics != nil {
+		start = time.Now()
+	}
+	newItem, err := p.callFactory(ctx, factory)
+	for attempt := 1; err != nil && attempt <= p.retries && retryable(err); attempt++ {
+		if err := p.retryWait(ctx, attempt); err != nil {
+			return nil, err
+		}
+		newItem, err = p.callFactory(ctx, factory)
 	}
+	if err != nil {
+		return nil, err
+	}
+	var took time.Duration
+	if p.metrics != nil {
+		took = time.Since(start)
+	}
+	return p.track(newItem, took), nil
+}
+
+// retryable reports whether a factory error may be retried.
+func retryable(err error) bool {
+	return !errors.Is(err, ErrFactoryPanic) && !errors.Is(err, ErrCircuitOpen)
+}
 
-	if p.initial > 0 {
-		// create initial number of items
-		var items []T
+// retryWait sleeps for the backoff of a factory retry, returning early with the
+// context error once ctx is done.
+func (p *Pool[T]) retryWait(ctx context.Context, attempt int) error {
+	if p.backoff == nil {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(p.backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
 
-		// create new items
-		for i := 0; i < p.initial; i++ {
-			items = append(items, p.Borrow(ctx))
+// createMany builds up to n new items, with the bulk factory if there is
+// one. On failure, it returns the items created so far with the error.
+func (p *Pool[T]) createMany(ctx context.Context, n int) ([]*entry[T], error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if p.bulkFactory != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return p.createBulk(n)
+	}
+	entries := make([]*entry[T], 0, n)
+	for len(entries) < n {
+		if err := ctx.Err(); err != nil {
+			return entries, err
 		}
-		// return new items
-		for j := len(items) - 1; j >= 0; j-- {
-			p.ReturnItem(items[j])
+		e, err := p.create(ctx)
+		if err != nil {
+			return entries, err
 		}
-		p.initial = 0
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// createBulk builds up to n new items with the bulk factory, turning a
+// panic into an error like callFactory does.
+func (p *Pool[T]) createBulk(n int) ([]*entry[T], error) {
+	var start time.Time
+	if p.metrics != nil {
+		start = time.Now()
+	}
+	items, err := func() (items []T, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				p.warn("sync: factory panicked", "panic", r)
+				err = fmt.Errorf("%w: %v", ErrFactoryPanic, r)
+			}
+		}()
+		return p.bulkFactory(n), nil
+	}()
+	if err != nil {
+		return nil, err
 	}
+	if len(items) > n {
+		items = items[:n]
+	}
+	var took time.Duration
+	if p.metrics != nil && len(items) > 0 {
+		// the items share the time it took to create them
+		took = time.Since(start) / time.Duration(len(items))
+	}
+	entries := make([]*entry[T], len(items))
+	for i, item := range items {
+		entries[i] = p.track(item, took)
+	}
+	return entries, nil
+}
+
+// track starts tracking a new item which took the factory took to create.
+func (p *Pool[T]) track(item T, took time.Duration) *entry[T] {
+	if p.metrics != nil {
+		p.emit(Created, took)
+	}
+	p.count.Add(1)
+	p.live.Add(1)
+	p.created.Add(1)
+	e := newEntry(item)
+	e.generation = p.generation.Load()
+	return e
+}
+
+// callFactory runs the factory, turning a panic into an error so that the
+// caller gives back the slot it holds.
+func (p *Pool[T]) callFactory(ctx context.Context, factory func(context.Context) (T, error)) (item T, err error) {
+	if p.breaker != nil {
+		if !p.breaker.allow() {
+			return item, ErrCircuitOpen
+		}
+		defer func() {
+			p.breaker.record(err, ctx.Err() != nil)
+		}()
+	}
+	if p.createTiming {
+		start := time.Now()
+		defer func() {
+			p.recordCreate(time.Since(start))
+		}()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.warn("sync: factory panicked", "panic", r)
+			err = fmt.Errorf("%w: %v", ErrFactoryPanic, r)
+		}
+	}()
+	return factory(ctx)
+}
+
+// dropped accounts for an idle item the garbage collector dropped.
+func (p *Pool[T]) dropped(e *entry[T]) {
+	p.idleCount.Add(-1)
+	p.unretain(e)
+	p.count.Add(-1)
+	p.destroyed.Add(1)
+	p.emit(Destroyed, 0)
+	p.wakeReplenisher()
+}
+
+// destroy evicts an item from the pool for good, returning the error of
+// closing it.
+func (p *Pool[T]) destroy(e *entry[T]) error {
+	if e.overflow || e.tier {
+		p.extra.Add(-1)
+	}
+	p.count.Add(-1)
+	p.live.Add(-1)
+	p.destroyed.Add(1)
+	p.emit(Destroyed, 0)
+	if p.destroyFn != nil {
+		p.destroyFn(e.item)
+		return nil
+	}
+	c, ok := any(e.item).(io.Closer)
+	if !ok {
+		return nil
+	}
+	err := c.Close()
+	if err != nil && p.onCloseError != nil {
+		p.onCloseError(e.item, err)
+	}
+	return err
+}
+
+// get takes an idle item out of the pool, creating one if none is idle.
+func (p *Pool[T]) get(ctx context.Context) (*entry[T], error) {
+	if e, ok := p.takeIdle(); ok {
+		return e, nil
+	}
+	if e, ok := p.awaitReuse(ctx); ok {
+		return e, nil
+	}
+	p.misses.Add(1)
+	return p.create(ctx)
+}
+
+// takeIdle takes a usable idle item out of the pool, destroying the
+// unusable ones it comes across.
+func (p *Pool[T]) takeIdle() (*entry[T], bool) {
+	size := int(p.size.Load())
+	for attempt := 0; size <= 0 || attempt < size; attempt++ {
+		e, ok := p.getIdle()
+		if !ok {
+			break
+		}
+		if p.usable(e) || p.refreshed(e) {
+			p.hits.Add(1)
+			return e, true
+		}
+		p.destroy(e)
+	}
+	return nil, false
+}
+
+// refreshed reports whether the refresh function rejuvenated an unusable
+// idle item.
+func (p *Pool[T]) refreshed(e *entry[T]) bool {
+	if p.refresh == nil || !hasIdentity(any(e.item)) {
+		return false
+	}
+	if err := p.refresh(e.item); err != nil {
+		p.warn("sync: refreshing item failed", "err", err)
+		return false
+	}
+	e.createdAt = time.Now()
+	e.generation = p.generation.Load()
+	e.uses = 0
+	return true
+}
+
+// usable reports whether an idle item may be handed out again.
+func (p *Pool[T]) usable(e *entry[T]) bool {
+	if p.stale(e) {
+		return false
+	}
+	if p.maxLifetime > 0 && time.Since(e.createdAt) > p.maxLifetime {
+		return false
+	}
+	if p.maxIdleTime > 0 && time.Since(e.idleSince) > p.maxIdleTime {
+		return false
+	}
+	validate := p.validateOnBorrow.load()
+	return validate == nil || validate(e.item)
 }
 
 // Borrow obtains an item from the pool.
 // If the Max option is set, then this function
-// will block until an item is returned back into the pool.
+// will block until an item is returned back into the pool
+// or ctx is done. If ctx is done before a slot frees up,
+// the zero value of T is returned and nothing needs to be
+// returned to the pool. Use BorrowErr to tell the two apart.
 //
 // After the item is no longer required, you must call
 // Return on the item.
+//
+// Borrow panics if the pool has no factory, the factory panicked or the
+// pool is a copy.
 func (p *Pool[T]) Borrow(ctx context.Context) T {
-	if p.semMax != nil {
-		p.semMax.Acquire(ctx, 1)
+	item, err := p.BorrowErr(ctx)
+	if errors.Is(err, ErrNoFactory) || errors.Is(err, ErrFactoryPanic) || errors.Is(err, ErrPoolCopied) {
+		panic(err)
+	}
+	return item
+}
+
+// BorrowErr obtains an item from the pool like Borrow, but reports
+// why no item could be obtained. When acquisition fails the zero value
+// of T is returned along with the error, and nothing needs to be
+// returned to the pool. ErrPoolClosed is returned once the pool is closed,
+// ErrNoFactory if no factory was set, ErrFactoryPanic if the factory
+// panicked, ErrTooManyWaiters if too many borrowers are waiting,
+// ErrWouldBlock if WithBorrowFailFast refuses to wait and
+// ErrBorrowAborted, wrapping the context error, if ctx is done first.
+func (p *Pool[T]) BorrowErr(ctx context.Context) (T, error) {
+	return p.borrowItem(ctx, borrowReq{})
+}
+
+// BorrowNew obtains an item like BorrowErr, also reporting whether the
+// item is new, i.e. handed out for the first time since the factory
+// created it, so that one-time setup such as registering a new connection
+// only runs on new items. Items created ahead of time, by bootstrapping,
+// Warmup or WithMinIdle, are new on their first borrow as well, and an
+// item refreshed by WithRefreshFunc counts as created anew.
+func (p *Pool[T]) BorrowNew(ctx context.Context) (T, bool, error) {
+	e, err := p.tracedBorrow(ctx, borrowReq{})
+	if err != nil {
+		var zero T
+		return zero, false, err
+	}
+	// new items have never been handed out
+	created := e.uses == 0
+	return p.borrowed(e), created, nil
+}
+
+// borrowReq describes how a single item, or the slots of a batch, are
+// borrowed.
+type borrowReq struct {
+	prio     int           // prio queues the borrow by priority, see BorrowPriority
+	weight   int64         // weight is the number of slots the item holds, see BorrowWeighted
+	noWait   bool          // noWait fails with ErrWouldBlock rather than wait for a slot
+	softWait time.Duration // softWait is how long to wait for an idle item, see BorrowSoft
+	hint     any           // hint is the idle item preferred, see BorrowAffine
+	batch    bool          // batch acquires slots for a batch, which never overflows
+}
+
+// slots returns the number of slots the borrowed item holds, at least 1.
+func (r borrowReq) slots() int64 {
+	if r.weight < 1 {
+		return 1
+	}
+	return r.weight
+}
+
+// slot is what a borrow was admitted with.
+type slot int
+
+const (
+	poolSlot     slot = iota // poolSlot is a slot of the pool itself
+	overflowSlot             // overflowSlot is no slot at all, see WithOverflow
+	tierSlot                 // tierSlot is a slot of the overflow tier
+)
+
+// borrowItem borrows a single item as req describes and runs the borrow
+// hooks on it. Every single item borrow goes through here.
+func (p *Pool[T]) borrowItem(ctx context.Context, req borrowReq) (T, error) {
+	e, err := p.tracedBorrow(ctx, req)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return p.borrowedWeighted(e, req.slots()), nil
+}
+
+// borrow acquires a slot and obtains an item without running any hooks.
+func (p *Pool[T]) borrow(ctx context.Context, req borrowReq) (*entry[T], error) {
+	s, err := p.admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.obtain(ctx, req, s)
+}
+
+// admit acquires a slot for a borrow, or the slots of a batch. Every borrow
+// goes through here. Once the pool is full, a pool with overflow items or
+// an overflow tier admits single slot borrows without waiting.
+func (p *Pool[T]) admit(ctx context.Context, req borrowReq) (slot, error) {
+	if p.self == nil {
+		return poolSlot, ErrPoolNotCreated
+	}
+	if p.self != p {
+		// a copy shares the slots and items of the pool it was copied from
+		return poolSlot, ErrPoolCopied
+	}
+	n := req.slots()
+	if req.batch {
+		if req.noWait {
+			return poolSlot, p.tryAcquire(n)
+		}
+		return poolSlot, p.acquire(ctx, n, 0)
+	}
+	if n == 1 && p.semMax != nil && (p.overflow || p.tier != nil) {
+		if p.closed.Load() {
+			return poolSlot, ErrPoolClosed
+		}
+		// a done context gets no item, even if one is at hand
+		if err := ctx.Err(); err != nil {
+			return poolSlot, err
+		}
+		if p.trySlots(1) {
+			return poolSlot, nil
+		}
+		if p.overflow {
+			return overflowSlot, nil
+		}
+		if p.queued.Load() == 0 && p.tier.sem.TryAcquire(1) {
+			return tierSlot, nil
+		}
+	}
+	if req.noWait {
+		return poolSlot, p.tryAcquire(n)
+	}
+	return poolSlot, p.acquire(ctx, n, req.prio)
+}
+
+// obtain gets an item for a borrow admitted with s, giving the slot back
+// on failure.
+func (p *Pool[T]) obtain(ctx context.Context, req borrowReq, s slot) (*entry[T], error) {
+	switch s {
+	case overflowSlot:
+		return p.borrowOverflow(ctx)
+	case tierSlot:
+		return p.borrowTier(ctx)
+	}
+	if req.hint != nil {
+		if e, ok := p.pickIdle(req.hint); ok {
+			p.raisePeak(p.inUse.Add(1), req.slots())
+			return e, nil
+		}
+	}
+	if req.softWait > 0 {
+		if e, ok := p.awaitIdle(ctx, req.softWait); ok {
+			p.raisePeak(p.inUse.Add(1), req.slots())
+			return e, nil
+		}
+	}
+	return p.take(ctx, req.slots())
+}
+
+// tryAcquire reserves slots for n items like acquire, but fails with
+// ErrWouldBlock instead of waiting while the pool is full.
+func (p *Pool[T]) tryAcquire(n int64) error {
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	if p.semMax != nil && !p.trySlots(n) {
+		return ErrWouldBlock
+	}
+	return nil
+}
+
+// acquireUnbounded reserves slots for n items of a pool without max size,
+// which never has to wait for one.
+func (p *Pool[T]) acquireUnbounded(ctx context.Context, n int64, prio int) error {
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	// a done context gets no item, even if one is at hand
+	return ctx.Err()
+}
+
+// acquireBounded reserves slots for n items, blocking while the pool is
+// full. Single slots are queued by prio while priority waiters are queued.
+func (p *Pool[T]) acquireBounded(ctx context.Context, n int64, prio int) error {
+	if p.closed.Load() {
+		return ErrPoolClosed
+	}
+	// a done context gets no item, even if one is at hand
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	var waited time.Duration
+	if !p.trySlots(n) {
+		if p.guard != nil && p.guard.holding() {
+			return ErrReentrantBorrow
+		}
+		if p.failFast != nil && p.failFast(p.Stats()) {
+			return ErrWouldBlock
+		}
+		// the pool is full, wait for a slot
+		if !p.startWaiting() {
+			return ErrTooManyWaiters
+		}
+		// make the slots cached by shards available to waiters
+		p.flushSlots()
+		start := time.Now()
+		if p.metrics != nil {
+			p.emit(WaitStarted, 0)
+		}
+		var err error
+		if n == 1 && (prio != 0 || p.queued.Load() > 0) {
+			err = p.waitTurn(ctx, prio)
+		} else {
+			err = p.semMax.Acquire(ctx, n)
+		}
+		waited = time.Since(start)
+		p.waiting.Add(-1)
+		if p.metrics != nil {
+			p.emit(WaitEnded, waited)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if p.closed.Load() {
+		// closed while waiting for a slot
+		p.release(n)
+		return ErrPoolClosed
+	}
+	p.recordWait(waited)
+	return nil
+}
+
+// startWaiting counts a borrower about to block, reporting false if
+// maxWaiters borrowers are already waiting.
+func (p *Pool[T]) startWaiting() bool {
+	for {
+		n := p.waiting.Load()
+		if p.maxWaiters > 0 && n >= int32(p.maxWaiters) {
+			return false
+		}
+		if p.waiting.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// borrowOverflow creates an overflow item, which holds no slot.
+func (p *Pool[T]) borrowOverflow(ctx context.Context) (*entry[T], error) {
+	p.misses.Add(1)
+	e, err := p.create(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.overflow = true
+	p.extra.Add(1)
+	p.raisePeak(p.inUse.Add(1), 1)
+	return e, nil
+}
+
+// take obtains an item for w acquired slots, giving them back on failure.
+func (p *Pool[T]) take(ctx context.Context, w int64) (*entry[T], error) {
+	e, err := p.get(ctx)
+	if err != nil {
+		p.release(w)
+		return nil, err
 	}
-	return p.syncPool.Get().(T)
+	p.raisePeak(p.inUse.Add(1), w)
+	return e, nil
+}
+
+// BorrowTimeout obtains an item from the pool like BorrowErr, waiting
+// at most d for a slot to free up. If the pool stays saturated for
+// longer than d, context.DeadlineExceeded is returned.
+func (p *Pool[T]) BorrowTimeout(d time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return p.BorrowErr(ctx)
+}
+
+// TryBorrow obtains an item from the pool without blocking.
+// If the pool has reached its max size, is closed or the factory
+// fails, the zero value of T and false are returned. When no max
+// size is set, or the pool overflows as set with WithOverflow,
+// TryBorrow always succeeds unless the factory fails.
+func (p *Pool[T]) TryBorrow() (T, bool) {
+	item, err := p.borrowItem(context.Background(), borrowReq{noWait: true})
+	return item, err == nil
+}
+
+// borrowed runs the borrow hook on an item about to be handed out and
+// records it as borrowed.
+func (p *Pool[T]) borrowed(e *entry[T]) T {
+	return p.borrowedWeighted(e, 1)
+}
+
+// borrowedWeighted is borrowed for an item holding w slots.
+func (p *Pool[T]) borrowedWeighted(e *entry[T], w int64) T {
+	e.weight = w
+	if onBorrow := p.onBorrow.load(); onBorrow != nil {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					p.freeEntry(e)
+					p.destroy(e)
+					panic(r)
+				}
+			}()
+			onBorrow(e.item)
+		}()
+	}
+	if hasIdentity(any(e.item)) {
+		p.watch(e)
+	} else if e.overflow {
+		// told apart from other items on return by count only
+		p.overflowed.Add(1)
+	} else if e.tier {
+		p.tier.anonymous.Add(1)
+	}
+	e.uses++
+	if p.guard != nil && hasIdentity(any(e.item)) {
+		e.borrower = p.guard.enter()
+	}
+	p.ledger.checkout(e)
+	p.emit(Borrowed, 0)
+	return e.item
 }
 
 // ReturnItem returns an item back to the pool.
+// If a reset function is set, it is called on the item first.
+// Once the pool is closed, or if the item fails the return
+// validation, is from an older Generation, has been borrowed
+// WithMaxUses times or too many items are idle, it is destroyed
+// instead.
+//
+// Returning an item that is not borrowed, e.g. a second time, is a no-op.
+// So is returning a nil item by mistake: it is never pooled, and the slot
+// of the item actually borrowed is only freed once that item is returned.
 func (p *Pool[T]) ReturnItem(item T) {
-	p.syncPool.Put(item)
-	if p.semMax != nil {
-		p.semMax.Release(1)
+	p.giveBack(item, 1, false)
+}
+
+// ReturnItemErr returns an item like ReturnItem, reporting ErrNotBorrowed
+// or ErrNilItem when the item was not borrowed, and ErrPoolNotCreated for
+// the zero value of a Pool.
+func (p *Pool[T]) ReturnItemErr(item T) error {
+	return p.giveBack(item, 1, false)
+}
+
+// Discard gives up a borrowed item for good, e.g. after detecting that it
+// is corrupt. Like ReturnItem it frees the slot of the item, but the item
+// is destroyed instead of being put back, without running the return
+// validation, the return policy or the reset function. Discarding an item
+// that is not borrowed is a no-op.
+func (p *Pool[T]) Discard(item T) {
+	p.giveBack(item, 1, true)
+}
+
+// giveBack returns an item like ReturnItem, or destroys it like Discard.
+// Items which can't be told apart are taken to hold w slots.
+func (p *Pool[T]) giveBack(item T, w int64, discard bool) error {
+	e, err := p.checkin(item)
+	if err != nil {
+		return err
+	}
+	healthy := true
+	action := Reset
+	if discard {
+		action = Discard
+	} else {
+		validate := p.validateOnReturn.load()
+		healthy = validate == nil || validate(item)
+		if policy := p.returnPolicy.load(); policy != nil && healthy {
+			action = policy(item)
+		}
+	}
+	if hasIdentity(any(item)) {
+		// items told apart remember the weight they were borrowed with, read
+		// it before the entry may be borrowed again
+		w = e.weight
 	}
+	if reset := p.reset.load(); action == Reset && reset != nil {
+		reset(item)
+	}
+	if e.overflow {
+		p.destroy(e)
+		p.unborrow(1)
+		return nil
+	}
+	worn := p.maxUses > 0 && e.uses >= p.maxUses
+	// a nil item the factory created is handed out once, never again
+	unusable := !healthy || action == Discard || worn || isNil(any(item)) || p.stale(e)
+	if e.tier {
+		p.returnTier(e, unusable)
+		return nil
+	}
+	if unusable || p.closed.Load() {
+		p.destroy(e)
+	} else {
+		p.putIdle(e)
+		if p.closed.Load() {
+			// lost a race with Close, make sure item does not linger
+			p.drain()
+		}
+	}
+	p.free(w)
+	return nil
+}
+
+// checkin takes an item back from its borrower, returning its entry. The
+// slot of the item is still held.
+func (p *Pool[T]) checkin(item T) (*entry[T], error) {
+	if p.self == nil {
+		// nothing can be borrowed from the zero value
+		return nil, ErrPoolNotCreated
+	}
+	e, ok := p.ledger.checkin(item)
+	if !ok {
+		if isNil(any(item)) {
+			p.warn("sync: returned item is nil, dropping it")
+			return nil, ErrNilItem
+		}
+		p.warn("sync: returned item is not borrowed, it may have been returned twice")
+		return nil, ErrNotBorrowed
+	}
+	if e == nil {
+		e = newEntry(item)
+		e.generation = p.generation.Load()
+		e.overflow = p.takeOverflowed()
+		e.tier = !e.overflow && p.tier != nil && p.takeTier()
+		e.uses = 1
+		e.weight = 1
+		p.emit(Returned, 0)
+		return e, nil
+	}
+	if p.metrics != nil {
+		p.emit(Returned, time.Since(e.borrowedAt))
+	}
+	p.unwatch(e)
+	if p.guard != nil {
+		p.guard.leave(e.borrower)
+	}
+	return e, nil
+}
+
+// Replace swaps a borrowed item which broke, e.g. a connection lost while
+// in use, for a new one from the factory. The old item is destroyed, and
+// the new one keeps its slot, so that Replace never waits for one. If the
+// factory fails, the slot is freed and the error returned; nothing needs
+// to be returned to the pool then. Replacing an item that is not borrowed
+// fails with ErrNotBorrowed, or ErrNilItem for a nil item.
+func (p *Pool[T]) Replace(ctx context.Context, old T) (T, error) {
+	var zero T
+	e, err := p.checkin(old)
+	if err != nil {
+		return zero, err
+	}
+	p.destroy(e)
+	replacement, err := p.create(ctx)
+	if err == nil && p.closed.Load() {
+		p.destroy(replacement)
+		err = ErrPoolClosed
+	}
+	if err != nil {
+		p.freeEntry(e)
+		return zero, err
+	}
+	replacement.overflow, replacement.tier = e.overflow, e.tier
+	if e.overflow || e.tier {
+		p.extra.Add(1)
+	}
+	return p.borrowedWeighted(replacement, e.weight), nil
+}
+
+// free gives up the w slots held by a borrowed item.
+func (p *Pool[T]) free(w int64) {
+	p.unborrow(w)
+	p.release(w)
+}
+
+// freeEntry is free for the borrowed item e, which holds a slot of the
+// overflow tier or none at all instead of its weight if it overflowed.
+func (p *Pool[T]) freeEntry(e *entry[T]) {
+	switch {
+	case e.overflow:
+		p.unborrow(1)
+	case e.tier:
+		p.tier.inUse.Add(-1)
+		p.unborrow(1)
+		p.tier.sem.Release(1)
+	default:
+		p.free(e.weight)
+	}
+}
+
+// unborrow stops counting an item holding w slots as borrowed.
+func (p *Pool[T]) unborrow(w int64) {
+	inUse := p.inUse.Add(-1)
+	if inUse == 0 && p.closed.Load() {
+		p.signalReturned()
+	}
+	if inUse == 0 && p.idleWaiting.Load() > 0 {
+		p.signalIdle()
+	}
+	if p.saturation != nil {
+		p.saturation.lowered(w, p.size.Load())
+	}
+}
+
+// signalReturned tells Shutdown that the last borrowed item is back.
+func (p *Pool[T]) signalReturned() {
+	select {
+	case p.allReturned <- struct{}{}:
+	default:
+	}
+}
+
+// signalIdle closes the channels returned by Idle.
+func (p *Pool[T]) signalIdle() {
+	p.idleMu.Lock()
+	defer p.idleMu.Unlock()
+	for _, ch := range p.idleWaiters {
+		close(ch)
+	}
+	p.idleWaiters = nil
+	p.idleWaiting.Store(0)
+}
+
+// takeOverflowed reports whether a returned item without identity is
+// counted as one of the borrowed overflow items, which are all alike.
+func (p *Pool[T]) takeOverflowed() bool {
+	for {
+		n := p.overflowed.Load()
+		if n <= 0 {
+			return false
+		}
+		if p.overflowed.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// releaseUnbounded gives up n slots of a pool without max size, which
+// has nothing to give back.
+func (p *Pool[T]) releaseUnbounded(n int64) {}
+
+// releaseBounded gives up n slots acquired from the semaphore. A single
+// slot is cached by the shards of the pool while nobody waits for one.
+func (p *Pool[T]) releaseBounded(n int64) {
+	if n == 1 && p.slots != nil && p.waiting.Load() == 0 && p.owed.Load() <= 0 {
+		p.slots.put()
+		if p.waiting.Load() > 0 || p.owed.Load() > 0 {
+			// a borrower started waiting or the pool shrank meanwhile
+			p.flushSlots()
+		}
+		p.wakeReplenisher()
+		return
+	}
+	p.releaseShared(n)
+}
+
+// releaseShared gives n slots back to the semaphore, keeping those owed
+// to an earlier shrink.
+func (p *Pool[T]) releaseShared(n int64) {
+	for n > 0 {
+		owed := p.owed.Load()
+		if owed <= 0 {
+			break
+		}
+		kept := owed
+		if kept > n {
+			kept = n
+		}
+		if p.owed.CompareAndSwap(owed, owed-kept) {
+			// keep the permits to pay off a shrink
+			n -= kept
+		}
+	}
+	if n > 0 {
+		p.semMax.Release(n)
+		p.wakeQueue()
+		p.wakeReplenisher()
+	}
+}
+
+// Close shuts the pool down. All idle items are destroyed and subsequent
+// borrows fail with ErrPoolClosed. Items that are still borrowed can be
+// returned as usual and are destroyed on return.
+//
+// Idle items implementing io.Closer are all closed, even if some fail to;
+// the errors are returned joined with errors.Join. Items destroyed after
+// Close returned only report to the WithCloseErrorHandler function.
+//
+// Goroutines blocked in Borrow when the pool is closed fail once a slot
+// frees up. Calling Close more than once is a no-op.
+func (p *Pool[T]) Close() error {
+	if !p.close() {
+		return nil
+	}
+	if n := p.inUse.Load(); n > 0 {
+		p.warn("sync: pool closed with items still borrowed, they are destroyed when returned", "borrowed", n)
+	}
+	return p.drain()
 }
 
-// Count returns approximately the number of items in the pool (idle and in-use).
-// If you want an accurate number, call runtime.GC() twice before calling Count (not recommended).
+// close stops borrows and the background goroutines, reporting false if
+// the pool was closed already.
+func (p *Pool[T]) close() bool {
+	if !p.closed.CompareAndSwap(false, true) {
+		return false
+	}
+	p.cancel()
+	p.background.Wait()
+	return true
+}
+
+// IsClosed reports whether Close or Shutdown has been called, even if
+// borrowed items are still to be returned and destroyed.
+func (p *Pool[T]) IsClosed() bool {
+	return p.closed.Load()
+}
+
+// Idle returns a channel which is closed once no item is borrowed, right
+// away if none is borrowed now. Each call returns a new channel, so that
+// every caller is told about the next moment all items are back.
+func (p *Pool[T]) Idle() <-chan struct{} {
+	ch := make(chan struct{})
+	p.idleMu.Lock()
+	p.idleWaiters = append(p.idleWaiters, ch)
+	p.idleWaiting.Store(int32(len(p.idleWaiters)))
+	p.idleMu.Unlock()
+	// checked after registering, so a concurrent last return either sees
+	// the channel or is seen here
+	if p.inUse.Load() == 0 {
+		p.signalIdle()
+	}
+	return ch
+}
+
+// Shutdown gracefully shuts the pool down. Like Close, it makes
+// subsequent borrows fail with ErrPoolClosed, but it then waits for all
+// borrowed items to be returned before destroying the idle ones. If ctx is
+// done first, Shutdown still destroys the idle items and returns an error
+// wrapping the context error which tells how many items are still
+// borrowed; they are destroyed when they are returned.
+//
+// Shutdown may be called after Close to wait for borrowed items.
+func (p *Pool[T]) Shutdown(ctx context.Context) error {
+	p.close()
+	for {
+		n := p.inUse.Load()
+		if n <= 0 {
+			break
+		}
+		select {
+		case <-p.allReturned:
+		case <-ctx.Done():
+			return errors.Join(fmt.Errorf("sync: %d items still borrowed: %w", n, ctx.Err()), p.drain())
+		}
+	}
+	return p.drain()
+}
+
+// Resize changes the max size of the pool at runtime.
+//
+// Growing takes effect immediately and wakes up blocked borrowers.
+// Shrinking never takes slots away from borrowed items: if more than
+// newMax items are borrowed, new borrowers block until enough of them
+// are returned to get below newMax.
+//
+// Like Purge, resizing starts a new generation: idle items are destroyed
+// right away and borrowed ones when they are returned, so the pool is
+// refilled with items created for its new size.
+//
+// Resize has no effect on unbounded pools or when newMax is below 1 or
+// above math.MaxInt32.
+func (p *Pool[T]) Resize(newMax int) {
+	if p.semMax == nil || newMax < 1 || newMax > maxCapacity {
+		return
+	}
+	p.resizeMu.Lock()
+	defer p.resizeMu.Unlock()
+
+	delta := int64(newMax) - int64(p.size.Swap(int32(newMax)))
+	if delta == 0 {
+		return
+	}
+	p.generation.Add(1)
+	p.flushSlots()
+	for delta > 0 {
+		// cancel permits still owed by an earlier shrink first
+		owed := p.owed.Load()
+		if owed <= 0 {
+			break
+		}
+		paid := owed
+		if paid > delta {
+			paid = delta
+		}
+		if p.owed.CompareAndSwap(owed, owed-paid) {
+			delta -= paid
+		}
+	}
+	for chunk := -delta; delta < 0 && chunk > 0; {
+		// take back free permits right away, in chunks halved whenever
+		// fewer permits than a chunk are free
+		if !p.semMax.TryAcquire(chunk) {
+			chunk /= 2
+			continue
+		}
+		delta += chunk
+		if chunk > -delta {
+			chunk = -delta
+		}
+	}
+	switch {
+	case delta > 0:
+		p.semMax.Release(delta)
+		p.wakeQueue()
+	case delta < 0:
+		// take the remaining permits back as borrowed items are returned
+		p.owed.Add(-delta)
+		// slots cached in the meantime pay off the shrink as well
+		p.flushSlots()
+	}
+	p.drain()
+}
+
+// drain destroys all idle items, returning the errors of closing them.
+func (p *Pool[T]) drain() error {
+	var errs []error
+	if p.tier != nil {
+		errs = p.drainTier()
+	}
+	for {
+		e, ok := p.getIdle()
+		if !ok {
+			return errors.Join(errs...)
+		}
+		if err := p.destroy(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+}
+
+// Purge destroys all idle items while keeping the pool open and starts a
+// new generation. Items borrowed at the time are destroyed when they are
+// returned instead of going back into the pool, so that afterwards the pool
+// only holds items created after the purge, e.g. after rotating credentials.
+//
+// Borrowed items which can't be told apart (see NewPointerPool) aren't
+// recognized when returned and are kept.
+func (p *Pool[T]) Purge() {
+	p.generation.Add(1)
+	p.drain()
+}
+
+// Generation returns the current generation of the pool. It starts at 0
+// and is incremented by every Purge and Resize; items created in an older
+// generation are destroyed instead of being reused.
+func (p *Pool[T]) Generation() uint64 {
+	return p.generation.Load()
+}
+
+// stale reports whether e was created before the last Purge or Resize.
+func (p *Pool[T]) stale(e *entry[T]) bool {
+	return e.generation < p.generation.Load()
+}
+
+// putIdle makes an item available to the next borrower, destroying it
+// instead when the max number of idle items is reached or the pool holds
+// more items than its max size after shrinking or its idle items would
+// take more than WithMaxBytes. With an eviction policy, a full pool may
+// destroy an idle item in its place. It reports whether the item was kept.
+func (p *Pool[T]) putIdle(e *entry[T]) bool {
+	if size := p.size.Load(); size > 0 && p.primaryCount() > size {
+		p.destroy(e)
+		return false
+	}
+	if !p.retain(e) {
+		p.destroy(e)
+		return false
+	}
+	e.idleSince = time.Now()
+	for p.maxIdle > 0 {
+		n := p.idleCount.Load()
+		if n >= int32(p.maxIdle) {
+			dropped := e
+			if s, ok := p.idle.(evictingStore[T]); ok && p.eviction != EvictReturned {
+				dropped = s.evict(e, p.eviction)
+			}
+			p.unretain(dropped)
+			p.destroy(dropped)
+			if dropped == e {
+				return false
+			}
+			p.wakeSoftWaiter()
+			return true
+		}
+		if p.idleCount.CompareAndSwap(n, n+1) {
+			p.idle.put(e)
+			p.wakeSoftWaiter()
+			return true
+		}
+	}
+	p.idleCount.Add(1)
+	p.idle.put(e)
+	p.wakeSoftWaiter()
+	return true
+}
+
+// getIdle takes an idle item out of the pool, if there is one.
+func (p *Pool[T]) getIdle() (*entry[T], bool) {
+	e, ok := p.idle.get()
+	if ok {
+		p.idleCount.Add(-1)
+		p.unretain(e)
+		p.wakeReplenisher()
+	}
+	return e, ok
+}
+
+// Count returns the number of items in the pool (idle and in-use).
+// Items are counted when the factory creates them and discounted when the
+// pool destroys them. With Ephemeral retention, idle items dropped by the
+// garbage collector are discounted lazily, once the collector has run
+// their finalizers.
 func (p *Pool[T]) Count() int32 {
 	return p.count.Load()
 }
 
+// primaryCount returns the number of items in the pool that count
+// against its size, leaving out overflow items.
+func (p *Pool[T]) primaryCount() int32 {
+	return p.count.Load() - p.extra.Load()
+}
+
+// LiveCount returns the number of items created and not yet destroyed by
+// the pool itself. Unlike Count, it never depends on the garbage collector:
+// idle items dropped by the collector in Ephemeral retention are still
+// counted. With Deterministic retention, where nothing is dropped, it is
+// exact at all times and equals Count.
+func (p *Pool[T]) LiveCount() int32 {
+	return p.live.Load()
+}
+
+// noCopy may be embedded into structs which must not be copied after first
+// use, for go vet's copylocks check to report copies.
 type noCopy struct{}
 
 func (*noCopy) Lock()   {}
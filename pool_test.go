@@ -2,10 +2,16 @@ package sync_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/kushsharma/go-sync"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"math/rand"
+	"os/exec"
+	"reflect"
 	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -27,6 +33,23 @@ func TestPool_Count(t *testing.T) {
 		})
 		assert.Equal(t, int32(5), itemPool.Count())
 	})
+	t.Run("should keep counting borrowed items across garbage collections", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](10),
+			sync.WithBootstrapItems[*Worker](3),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+
+		// idle items may be dropped by the collector, borrowed ones may not
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			return itemPool.Count() == 1
+		}, time.Second, 10*time.Millisecond)
+		itemPool.ReturnItem(worker)
+	})
 }
 
 func TestPool_Borrow(t *testing.T) {
@@ -85,4 +108,2200 @@ func TestPool_Borrow(t *testing.T) {
 		itemPool.ReturnItem(worker2)
 		itemPool.ReturnItem(worker3)
 	})
+	t.Run("should not hand out an item when context is cancelled while blocked", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1 := itemPool.Borrow(ctx)
+
+		cancelCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		worker2 := itemPool.Borrow(cancelCtx)
+		assert.Nil(t, worker2)
+
+		// the slot held by worker1 must still be the only one taken
+		itemPool.ReturnItem(worker1)
+		worker3 := itemPool.Borrow(ctx)
+		assert.NotNil(t, worker3)
+		itemPool.ReturnItem(worker3)
+	})
+}
+
+func TestPool_BorrowErr(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should return item and no error when slot is free", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+		assert.NotNil(t, worker)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should not hand out items for a cancelled context", func(t *testing.T) {
+		for name, opt := range map[string]sync.PoolOption[*Worker]{
+			"bounded":   sync.WithSize[*Worker](1),
+			"unbounded": sync.WithUnlimited[*Worker](),
+		} {
+			t.Run(name, func(t *testing.T) {
+				var created atomic.Int32
+				itemPool := sync.NewPool[*Worker](
+					opt,
+					sync.WithFactory[*Worker](func() *Worker {
+						created.Add(1)
+						return &Worker{id: rand.Intn(1000)}
+					}),
+				)
+				cancelled, cancel := context.WithCancel(ctx)
+				cancel()
+				worker, err := itemPool.BorrowErr(cancelled)
+				assert.ErrorIs(t, err, context.Canceled)
+				assert.Nil(t, worker)
+				assert.Equal(t, int32(0), created.Load())
+				assert.Equal(t, 0, itemPool.Stats().InUse)
+			})
+		}
+	})
+	t.Run("should return context error when pool stays saturated", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1, err := itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		worker2, err := itemPool.BorrowErr(timeoutCtx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, worker2)
+
+		itemPool.ReturnItem(worker1)
+	})
+	t.Run("should wrap context errors with ErrBorrowAborted", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err := itemPool.BorrowErr(cancelled)
+		assert.ErrorIs(t, err, sync.ErrBorrowAborted)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = itemPool.BorrowBatch(cancelled, 1)
+		assert.ErrorIs(t, err, sync.ErrBorrowAborted)
+		_, err = itemPool.BorrowWeighted(cancelled, 1)
+		assert.ErrorIs(t, err, sync.ErrBorrowAborted)
+
+		// other errors are left as they are
+		itemPool.ReturnItem(worker)
+		itemPool.Close()
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrPoolClosed)
+		assert.NotErrorIs(t, err, sync.ErrBorrowAborted)
+	})
+}
+
+func TestPool_TryBorrow(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should fail fast when max size is reached", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		assert.NotNil(t, worker1)
+
+		worker2, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+		assert.Nil(t, worker2)
+
+		itemPool.ReturnItem(worker1)
+		worker2, ok = itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker2)
+	})
+	t.Run("should always succeed when pool is unbounded", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker]()
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		var workers []*Worker
+		for i := 0; i < 10; i++ {
+			worker, ok := itemPool.TryBorrow()
+			assert.True(t, ok)
+			workers = append(workers, worker)
+		}
+		for _, worker := range workers {
+			itemPool.ReturnItem(worker)
+		}
+	})
+	t.Run("should overflow instead of failing", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflow[*Worker](true),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		worker2, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker2)
+		itemPool.ReturnItem(worker1)
+		assert.Equal(t, int32(1), itemPool.Count())
+	})
+}
+
+func TestPool_BorrowTimeout(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should give up after the timeout when pool stays saturated", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1, err := itemPool.BorrowTimeout(time.Second)
+		assert.NoError(t, err)
+
+		timeBeforeRequest := time.Now()
+		worker2, err := itemPool.BorrowTimeout(50 * time.Millisecond)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Nil(t, worker2)
+		assert.GreaterOrEqual(t, time.Since(timeBeforeRequest), 50*time.Millisecond)
+
+		itemPool.ReturnItem(worker1)
+	})
+}
+
+func TestPool_SetFactoryErr(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should propagate factory error and release the slot", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		dialErr := errors.New("dial failed")
+		fail := true
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			if fail {
+				return nil, dialErr
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, dialErr)
+		assert.Nil(t, worker)
+		assert.Equal(t, int32(0), itemPool.Count())
+
+		// the failed borrow must not hold on to the only slot
+		fail = false
+		worker, err = itemPool.BorrowTimeout(50 * time.Millisecond)
+		assert.NoError(t, err)
+		assert.NotNil(t, worker)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_SetTypedFactory(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should create items from a typed factory", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithBootstrapItems[*Worker](1),
+		)
+		itemPool.SetTypedFactory(ctx, func() *Worker {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		assert.Equal(t, int32(1), itemPool.Count())
+
+		worker := itemPool.Borrow(ctx)
+		assert.NotNil(t, worker)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithResetFunc(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should reset item on return", func(t *testing.T) {
+		resets := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithBootstrapItems[*Worker](1),
+			sync.WithResetFunc[*Worker](func(w *Worker) {
+				resets++
+				w.id = 0
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 1 + rand.Intn(1000)}
+		})
+		// bootstrap items were never borrowed
+		assert.Equal(t, 0, resets)
+
+		worker := itemPool.Borrow(ctx)
+		assert.NotEqual(t, 0, worker.id)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 1, resets)
+		assert.Equal(t, 0, worker.id)
+	})
+}
+
+func TestPool_WithOnBorrow(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should run hook before item is handed out", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOnBorrow[*Worker](func(w *Worker) {
+				w.id = -1
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+		assert.Equal(t, -1, worker.id)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should release slot when hook panics", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOnBorrow[*Worker](func(w *Worker) {
+				if w.id == 0 {
+					panic("bad worker")
+				}
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 0}
+		})
+		assert.Panics(t, func() {
+			itemPool.Borrow(ctx)
+		})
+
+		// the only slot must be free again
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 1}
+		})
+		worker, err := itemPool.BorrowTimeout(50 * time.Millisecond)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithDestroyFunc(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should destroy item rejected by the borrow hook", func(t *testing.T) {
+		var destroyed []*Worker
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOnBorrow[*Worker](func(w *Worker) {
+				panic("bad worker")
+			}),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed = append(destroyed, w)
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		assert.Panics(t, func() {
+			itemPool.Borrow(ctx)
+		})
+		assert.Len(t, destroyed, 1)
+		assert.Equal(t, int32(0), itemPool.Count())
+	})
+}
+
+func TestPool_Close(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should destroy idle items and reject borrows", func(t *testing.T) {
+		destroyed := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed++
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker1)
+
+		assert.False(t, itemPool.IsClosed())
+		assert.NoError(t, itemPool.Close())
+		assert.True(t, itemPool.IsClosed())
+		assert.Equal(t, 1, destroyed)
+
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrPoolClosed)
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+
+		// in-flight items are destroyed on return
+		itemPool.ReturnItem(worker2)
+		assert.Equal(t, 2, destroyed)
+		assert.Equal(t, int32(0), itemPool.Count())
+
+		assert.NoError(t, itemPool.Close())
+	})
+}
+
+func TestPool_WithValidateOnBorrow(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should skip stale items and fall through to the factory", func(t *testing.T) {
+		destroyed := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithValidateOnBorrow[*Worker](func(w *Worker) bool {
+				return w.id >= 0
+			}),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed++
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		var workers []*Worker
+		for i := 0; i < 3; i++ {
+			workers = append(workers, itemPool.Borrow(ctx))
+		}
+		for _, worker := range workers {
+			// mark every idle item as stale
+			worker.id = -1
+			itemPool.ReturnItem(worker)
+		}
+
+		worker := itemPool.Borrow(ctx)
+		assert.GreaterOrEqual(t, worker.id, 0)
+		assert.Equal(t, 3, destroyed)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithValidateOnReturn(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should destroy unhealthy items on return", func(t *testing.T) {
+		destroyed := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithValidateOnReturn[*Worker](func(w *Worker) bool {
+				return w.id >= 0
+			}),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed++
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+		worker.id = -1
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 1, destroyed)
+		assert.Equal(t, int32(0), itemPool.Count())
+
+		// slot was released with the discarded item
+		worker, err := itemPool.BorrowTimeout(50 * time.Millisecond)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, worker.id, 0)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithRetentionMode(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should keep idle items across garbage collections in deterministic mode", func(t *testing.T) {
+		created := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			created++
+			return &Worker{id: rand.Intn(1000)}
+		})
+		runtime.GC()
+		runtime.GC()
+		assert.Equal(t, int32(3), itemPool.Count())
+
+		var workers []*Worker
+		for i := 0; i < 3; i++ {
+			workers = append(workers, itemPool.Borrow(ctx))
+		}
+		assert.Equal(t, 3, created)
+		for _, worker := range workers {
+			itemPool.ReturnItem(worker)
+		}
+	})
+}
+
+func TestPool_WithMaxIdle(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should destroy returned items beyond the idle cap", func(t *testing.T) {
+		destroyed := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](5),
+			sync.WithMaxIdle[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed++
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		var workers []*Worker
+		for i := 0; i < 5; i++ {
+			workers = append(workers, itemPool.Borrow(ctx))
+		}
+		for _, worker := range workers {
+			itemPool.ReturnItem(worker)
+		}
+		assert.Equal(t, 3, destroyed)
+		assert.Equal(t, int32(2), itemPool.Count())
+		assert.Equal(t, 2, itemPool.Stats().Idle)
+	})
+}
+
+func TestPool_WithMaxLifetime(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should destroy idle items older than max lifetime", func(t *testing.T) {
+		var destroyed []*Worker
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithMaxLifetime[*Worker](50*time.Millisecond),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed = append(destroyed, w)
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker1)
+
+		// still young, reused
+		worker := itemPool.Borrow(ctx)
+		assert.Same(t, worker1, worker)
+		time.Sleep(60 * time.Millisecond)
+		itemPool.ReturnItem(worker)
+
+		// lifetime is counted from creation, not from the last return
+		worker = itemPool.Borrow(ctx)
+		assert.NotSame(t, worker1, worker)
+		assert.Equal(t, []*Worker{worker1}, destroyed)
+		assert.Equal(t, int32(1), itemPool.Count())
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_Resize(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should wake up blocked borrowers when growing", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1 := itemPool.Borrow(ctx)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			itemPool.Resize(2)
+		}()
+		worker2, err := itemPool.BorrowTimeout(time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, itemPool.Stats().MaxSize)
+
+		itemPool.ReturnItem(worker1)
+		itemPool.ReturnItem(worker2)
+	})
+	t.Run("should shrink as borrowed items are returned", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		itemPool.Resize(1)
+
+		// both borrowed items keep their slot
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker1)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker2)
+		// both items are from before the resize and are not kept
+		assert.Equal(t, int32(0), itemPool.Count())
+
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker)
+
+		// growing again cancels nothing already taken back
+		itemPool.Resize(2)
+		worker1, ok = itemPool.TryBorrow()
+		assert.True(t, ok)
+		worker2, ok = itemPool.TryBorrow()
+		assert.True(t, ok)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker1)
+		itemPool.ReturnItem(worker2)
+	})
+	t.Run("should resize between the smallest and the largest size", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](math.MaxInt32),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		itemPool.Resize(1)
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker1)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+
+		// the permit still owed is cancelled before any is handed out
+		itemPool.Resize(math.MaxInt32)
+		workers, err := itemPool.BorrowBatch(ctx, 1000)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+		itemPool.Resize(2)
+		worker1, ok = itemPool.TryBorrow()
+		assert.True(t, ok)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker1)
+		itemPool.ReturnItem(worker2)
+	})
+	t.Run("should ignore a size above math.MaxInt32", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		size := math.MaxInt32
+		itemPool.Resize(size + 1)
+		assert.Equal(t, 1, itemPool.Stats().MaxSize)
+	})
+}
+
+func TestPool_ReturnItem(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should ignore an item returned twice", func(t *testing.T) {
+		resets := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithResetFunc[*Worker](func(w *Worker) {
+				resets++
+			}),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 1, resets)
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+
+		// the max size still holds
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should ignore extra returns of value items", func(t *testing.T) {
+		itemPool := sync.NewPool[int](
+			sync.WithSize[int](1),
+		)
+		itemPool.SetTypedFactory(ctx, func() int {
+			return rand.Intn(1000)
+		})
+		item := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(item)
+		itemPool.ReturnItem(item)
+
+		item, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(item)
+	})
+}
+
+func TestPool_WithFactory(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should be ready to use once constructed", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Equal(t, int32(2), itemPool.Count())
+
+		worker := itemPool.Borrow(ctx)
+		assert.NotNil(t, worker)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_NoFactory(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should report a missing factory", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrNoFactory)
+		assert.Nil(t, worker)
+		assert.PanicsWithError(t, sync.ErrNoFactory.Error(), func() {
+			itemPool.Borrow(ctx)
+		})
+
+		// the failed borrows gave their slot back
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_SetFactory(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should bootstrap only once when called twice", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](5),
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 1}
+		})
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 2}
+		})
+		assert.Equal(t, int32(2), itemPool.Count())
+		assert.Equal(t, uint64(2), itemPool.Stats().Created)
+
+		// new items come from the replacing factory
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, workers[2].id)
+		itemPool.ReturnBatch(workers)
+	})
+	t.Run("should replace the factory while borrowing", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: 1}
+			}),
+		)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				itemPool.SetTypedFactory(ctx, func() *Worker {
+					return &Worker{id: 2}
+				})
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			worker := itemPool.Borrow(ctx)
+			assert.Contains(t, []int{1, 2}, worker.id)
+		}
+		<-done
+		assert.Equal(t, 2, itemPool.Borrow(ctx).id)
+	})
+}
+
+func TestPool_FactoryPanic(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should not lose capacity when the factory panics", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			panic("misconfigured")
+		})
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrFactoryPanic)
+		assert.ErrorContains(t, err, "misconfigured")
+		assert.Panics(t, func() {
+			itemPool.Borrow(ctx)
+		})
+
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker, err := itemPool.BorrowTimeout(50 * time.Millisecond)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithOrdering(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should rotate through items in FIFO order", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithOrdering[*Worker](sync.FIFO),
+		)
+		next := 0
+		itemPool.SetFactory(ctx, func() interface{} {
+			next++
+			return &Worker{id: next}
+		})
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+
+		var ids []int
+		for i := 0; i < 6; i++ {
+			worker := itemPool.Borrow(ctx)
+			ids = append(ids, worker.id)
+			itemPool.ReturnItem(worker)
+		}
+		assert.Equal(t, []int{1, 2, 3, 1, 2, 3}, ids)
+	})
+}
+
+func TestPool_Warmup(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should create idle items up to max size", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](5),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.NoError(t, itemPool.Warmup(ctx, 10))
+		assert.Equal(t, int32(5), itemPool.Count())
+		assert.Equal(t, 4, itemPool.Stats().Idle)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should stop when the context is done", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](5),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, itemPool.Warmup(cancelled, 3), context.Canceled)
+		assert.Equal(t, int32(0), itemPool.Count())
+	})
+	t.Run("should fail on a closed pool", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.NoError(t, itemPool.Close())
+		assert.ErrorIs(t, itemPool.Warmup(ctx, 3), sync.ErrPoolClosed)
+	})
+}
+
+func TestPool_Purge(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should destroy idle and previously borrowed items", func(t *testing.T) {
+		var destroyed atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed.Add(1)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers[1:])
+
+		itemPool.Purge()
+		assert.Equal(t, int32(2), destroyed.Load())
+		assert.Equal(t, int32(1), itemPool.Count())
+
+		itemPool.ReturnItem(workers[0])
+		assert.Equal(t, int32(3), destroyed.Load())
+		assert.Equal(t, int32(0), itemPool.Count())
+
+		worker := itemPool.Borrow(ctx)
+		assert.NotNil(t, worker)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 1, itemPool.Stats().Idle)
+	})
+}
+
+func TestPool_Generation(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should start a new generation on purge and resize", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Equal(t, uint64(0), itemPool.Generation())
+		itemPool.Purge()
+		assert.Equal(t, uint64(1), itemPool.Generation())
+
+		worker := itemPool.Borrow(ctx)
+		itemPool.Resize(2)
+		assert.Equal(t, uint64(1), itemPool.Generation())
+		itemPool.Resize(3)
+		assert.Equal(t, uint64(2), itemPool.Generation())
+
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, int32(0), itemPool.Count())
+	})
+}
+
+type closingWorker struct {
+	closed bool
+	err    error
+}
+
+func (w *closingWorker) Close() error {
+	w.closed = true
+	return w.err
+}
+
+func TestPool_CloserItems(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should close evicted items", func(t *testing.T) {
+		var failed []error
+		itemPool := sync.NewPool[*closingWorker](
+			sync.WithRetentionMode[*closingWorker](sync.Deterministic),
+			sync.WithCloseErrorHandler[*closingWorker](func(w *closingWorker, err error) {
+				failed = append(failed, err)
+			}),
+			sync.WithFactory[*closingWorker](func() *closingWorker {
+				return &closingWorker{err: errors.New("broken pipe")}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		itemPool.Purge()
+		assert.True(t, worker.closed)
+		assert.Len(t, failed, 1)
+		assert.ErrorContains(t, failed[0], "broken pipe")
+	})
+	t.Run("should prefer the destroy function", func(t *testing.T) {
+		itemPool := sync.NewPool[*closingWorker](
+			sync.WithRetentionMode[*closingWorker](sync.Deterministic),
+			sync.WithDestroyFunc[*closingWorker](func(w *closingWorker) {}),
+			sync.WithFactory[*closingWorker](func() *closingWorker {
+				return &closingWorker{}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		itemPool.Purge()
+		assert.False(t, worker.closed)
+	})
+}
+
+func TestPool_CloseErrors(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should close all items and join their errors", func(t *testing.T) {
+		errBroken := errors.New("broken pipe")
+		next := 0
+		itemPool := sync.NewPool[*closingWorker](
+			sync.WithSize[*closingWorker](3),
+			sync.WithRetentionMode[*closingWorker](sync.Deterministic),
+			sync.WithFactory[*closingWorker](func() *closingWorker {
+				next++
+				if next == 2 {
+					return &closingWorker{}
+				}
+				return &closingWorker{err: fmt.Errorf("worker %d: %w", next, errBroken)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+
+		err = itemPool.Close()
+		assert.ErrorIs(t, err, errBroken)
+		assert.ErrorContains(t, err, "worker 1")
+		assert.ErrorContains(t, err, "worker 3")
+		for _, worker := range workers {
+			assert.True(t, worker.closed)
+		}
+		assert.NoError(t, itemPool.Close())
+	})
+}
+
+func TestPool_SetFactoryCtx(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should cancel creating an item with the borrow", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](sync.WithSize[*Worker](1))
+		itemPool.SetFactoryCtx(ctx, func(ctx context.Context) (interface{}, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				return &Worker{id: rand.Intn(1000)}, nil
+			}
+		})
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := itemPool.BorrowErr(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(0), itemPool.Count())
+
+		// the slot was released
+		itemPool.SetFactoryCtx(ctx, func(ctx context.Context) (interface{}, error) {
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should pass the values of the borrow to the factory", func(t *testing.T) {
+		type tenantKey struct{}
+		itemPool := sync.NewPool[*Worker](sync.WithSize[*Worker](1))
+		itemPool.SetFactoryCtx(ctx, func(ctx context.Context) (interface{}, error) {
+			return &Worker{id: ctx.Value(tenantKey{}).(int)}, nil
+		})
+		worker := itemPool.Borrow(context.WithValue(ctx, tenantKey{}, 42))
+		assert.Equal(t, 42, worker.id)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithMaxWaiters(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should reject borrowers beyond the max waiters", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithMaxWaiters[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+
+		waited := make(chan error)
+		go func() {
+			w, err := itemPool.BorrowErr(ctx)
+			itemPool.ReturnItem(w)
+			waited <- err
+		}()
+		assert.Eventually(t, func() bool {
+			return itemPool.Waiters() == 1
+		}, time.Second, time.Millisecond)
+
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrTooManyWaiters)
+
+		itemPool.ReturnItem(worker)
+		assert.NoError(t, <-waited)
+	})
+}
+
+func TestPool_WithBorrowFailFast(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should fail instead of blocking when the predicate says so", func(t *testing.T) {
+		var seen sync.Stats
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithBorrowFailFast[*Worker](func(stats sync.Stats) bool {
+				seen = stats
+				return stats.Waiting >= 1
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+
+		// the first borrower may wait, the predicate turns away the next
+		waited := make(chan error)
+		go func() {
+			w, err := itemPool.BorrowErr(ctx)
+			itemPool.ReturnItem(w)
+			waited <- err
+		}()
+		assert.Eventually(t, func() bool {
+			return itemPool.Waiters() == 1
+		}, time.Second, time.Millisecond)
+
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrWouldBlock)
+		assert.Equal(t, 2, seen.InUse)
+		assert.Equal(t, 1, seen.Waiting)
+
+		itemPool.ReturnBatch(workers)
+		assert.NoError(t, <-waited)
+	})
+	t.Run("should not be consulted while slots are free", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithBorrowFailFast[*Worker](func(sync.Stats) bool {
+				return true
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrWouldBlock)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_Shutdown(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should wait for borrowed items", func(t *testing.T) {
+		var destroyed atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed.Add(1)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			itemPool.ReturnBatch(workers)
+		}()
+
+		assert.NoError(t, itemPool.Shutdown(ctx))
+		assert.Equal(t, int32(2), destroyed.Load())
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrPoolClosed)
+	})
+	t.Run("should report items still borrowed", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		err := itemPool.Shutdown(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "1 items still borrowed")
+		itemPool.ReturnItem(worker)
+		assert.NoError(t, itemPool.Shutdown(ctx))
+	})
+	t.Run("should destroy idle items when timing out before a Close", func(t *testing.T) {
+		var destroyed atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithDestroyFunc[*Worker](func(w *Worker) {
+				destroyed.Add(1)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(workers[0])
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, itemPool.Shutdown(timeout), context.DeadlineExceeded)
+		assert.NoError(t, itemPool.Close())
+		assert.Empty(t, itemPool.IdleItems())
+		assert.Equal(t, int32(1), destroyed.Load())
+
+		itemPool.ReturnItem(workers[1])
+		assert.Equal(t, int32(2), destroyed.Load())
+	})
+}
+
+func TestPool_LiveCount(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should count items regardless of garbage collections", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](10),
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		// no borrow here: the race detector makes sync.Pool drop items at
+		// random, so a borrow could miss the idle items and create another
+		runtime.GC()
+		runtime.GC()
+		assert.Equal(t, int32(3), itemPool.LiveCount())
+	})
+	t.Run("should discount destroyed items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), itemPool.LiveCount())
+		itemPool.ReturnBatch(workers)
+		itemPool.Purge()
+		assert.Equal(t, int32(0), itemPool.LiveCount())
+		assert.Equal(t, itemPool.Count(), itemPool.LiveCount())
+	})
+}
+
+func TestPool_WithSize(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should reject a size below 1", func(t *testing.T) {
+		for _, size := range []int{0, -1} {
+			assert.PanicsWithError(t, fmt.Sprintf("sync: invalid pool configuration: size must be at least 1, got %d, use WithUnlimited for an unbounded pool", size), func() {
+				sync.NewPool[*Worker](sync.WithSize[*Worker](size))
+			})
+		}
+	})
+	t.Run("should reject a size or bootstrap count above math.MaxInt32", func(t *testing.T) {
+		size := math.MaxInt32
+		_, err := sync.NewPoolErr[*Worker](sync.WithSize[*Worker](size + 1))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+		_, err = sync.NewPoolErr[*Worker](sync.WithBootstrapItems[*Worker](size + 1))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+	t.Run("should not limit an unlimited pool", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithUnlimited[*Worker](),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, itemPool.Stats().MaxSize)
+		itemPool.ReturnBatch(workers)
+	})
+	t.Run("should not limit an unlimited pool to its bootstrap items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithUnlimited[*Worker](),
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Equal(t, int32(2), itemPool.Count())
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		workers, err := itemPool.BorrowBatch(timeout, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, itemPool.Stats().MaxSize)
+		itemPool.ReturnBatch(workers)
+	})
+	t.Run("should limit a pool without a size to its bootstrap items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Equal(t, 2, itemPool.Stats().MaxSize)
+	})
+}
+
+func TestPool_NewPoolErr(t *testing.T) {
+	t.Run("should reject inconsistent options", func(t *testing.T) {
+		for _, opts := range [][]sync.PoolOption[*Worker]{
+			{sync.WithSize[*Worker](2), sync.WithBootstrapItems[*Worker](3)},
+			{sync.WithSize[*Worker](2), sync.WithMaxIdle[*Worker](3)},
+			{sync.WithSize[*Worker](2), sync.WithMinIdle[*Worker](3)},
+			{sync.WithMaxIdle[*Worker](1), sync.WithMinIdle[*Worker](2)},
+			{sync.WithBootstrapItems[*Worker](-1)},
+			{sync.WithMaxLifetime[*Worker](-time.Second)},
+		} {
+			itemPool, err := sync.NewPoolErr(opts...)
+			assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+			assert.Nil(t, itemPool)
+			assert.Panics(t, func() {
+				sync.NewPool(opts...)
+			})
+		}
+	})
+	t.Run("should describe every invalid option", func(t *testing.T) {
+		_, err := sync.NewPoolErr(
+			sync.WithSize[*Worker](2),
+			sync.WithMaxIdle[*Worker](3),
+			sync.WithMinIdle[*Worker](4),
+		)
+		assert.ErrorContains(t, err, "max idle (3) exceeds the size (2)")
+		assert.ErrorContains(t, err, "min idle (4) exceeds the size (2)")
+	})
+	t.Run("should create a pool from valid options", func(t *testing.T) {
+		itemPool, err := sync.NewPoolErr(
+			sync.WithSize[*Worker](2),
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithMaxIdle[*Worker](2),
+		)
+		assert.NoError(t, err)
+		assert.NotNil(t, itemPool)
+	})
+}
+
+func TestPool_WithLazyBootstrap(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should create bootstrap items in the background", func(t *testing.T) {
+		release := make(chan struct{})
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithLazyBootstrap[*Worker](),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				<-release
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		// the pool is usable while the factory is still blocked
+		assert.Equal(t, int32(0), itemPool.Count())
+		close(release)
+		assert.Eventually(t, func() bool {
+			return itemPool.Count() == 3
+		}, time.Second, time.Millisecond)
+		assert.Equal(t, 3, itemPool.Stats().Idle)
+		assert.NoError(t, itemPool.Close())
+	})
+	t.Run("should report bootstrap errors to the logger", func(t *testing.T) {
+		warned := make(chan string, 1)
+		itemPool := sync.NewPool[*Worker](
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithLazyBootstrap[*Worker](),
+			sync.WithLogger[*Worker](func(msg string, keyvals ...any) {
+				warned <- msg
+			}),
+		)
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			return nil, errors.New("connection refused")
+		})
+		select {
+		case msg := <-warned:
+			assert.Contains(t, msg, "bootstrapping items failed")
+		case <-time.After(time.Second):
+			t.Fatal("expected a bootstrap warning")
+		}
+		assert.NoError(t, itemPool.Close())
+	})
+}
+
+func TestPool_Bootstrap(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should fill the pool when initial equals max", func(t *testing.T) {
+		borrows := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithOnBorrow[*Worker](func(w *Worker) {
+				borrows++
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Equal(t, int32(3), itemPool.Count())
+		assert.Equal(t, 0, borrows)
+
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(3), itemPool.Stats().Created)
+		itemPool.ReturnBatch(workers)
+	})
+	t.Run("should not take slots from borrowers", func(t *testing.T) {
+		release := make(chan struct{})
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithLazyBootstrap[*Worker](),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				<-release
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		close(release)
+		// borrowers get every slot while bootstrapping is under way
+		workers, err := itemPool.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return itemPool.Count() == 2
+		}, time.Second, time.Millisecond)
+		itemPool.ReturnBatch(workers)
+		assert.Equal(t, 2, itemPool.Stats().Idle)
+		assert.NoError(t, itemPool.Close())
+	})
+}
+
+func TestPool_NewPointerPool(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should allocate items without a factory", func(t *testing.T) {
+		itemPool := sync.NewPointerPool[Worker](sync.WithSize[*Worker](1))
+		worker := itemPool.Borrow(ctx)
+		assert.NotNil(t, worker)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should prefer the factory of the options", func(t *testing.T) {
+		itemPool := sync.NewPointerPool[Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: 7}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.Equal(t, 7, worker.id)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_ValueTypes(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should keep exact counts for slices", func(t *testing.T) {
+		itemPool := sync.NewPool[[]byte](
+			sync.WithSize[[]byte](2),
+			sync.WithFactory[[]byte](func() []byte {
+				return make([]byte, 64)
+			}),
+		)
+		buf1 := itemPool.Borrow(ctx)
+		buf2 := itemPool.Borrow(ctx)
+		assert.Len(t, buf1, 64)
+		itemPool.ReturnItem(buf1)
+		itemPool.ReturnItem(buf2)
+		runtime.GC()
+		assert.Equal(t, int32(2), itemPool.Count())
+		assert.Equal(t, int32(2), itemPool.LiveCount())
+		assert.Equal(t, 2, itemPool.Stats().Idle)
+	})
+	t.Run("should not free more slots than were borrowed", func(t *testing.T) {
+		itemPool := sync.NewPool[[4]int](
+			sync.WithSize[[4]int](1),
+			sync.WithFactory[[4]int](func() [4]int {
+				return [4]int{1, 2, 3, 4}
+			}),
+		)
+		item := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(item)
+		itemPool.ReturnItem(item)
+		assert.Equal(t, int32(1), itemPool.Count())
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+	})
+}
+
+func BenchmarkPool_Borrow(b *testing.B) {
+	ctx := context.Background()
+	for _, bench := range []struct {
+		name string
+		opts []sync.PoolOption[*Worker]
+	}{
+		{name: "bounded", opts: []sync.PoolOption[*Worker]{sync.WithSize[*Worker](64)}},
+		{name: "unbounded", opts: []sync.PoolOption[*Worker]{sync.WithUnlimited[*Worker]()}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			itemPool := sync.NewPool[*Worker](append(bench.opts,
+				sync.WithRetentionMode[*Worker](sync.Deterministic),
+				sync.WithFactory[*Worker](func() *Worker {
+					return &Worker{id: rand.Intn(1000)}
+				}),
+			)...)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				itemPool.ReturnItem(itemPool.Borrow(ctx))
+			}
+		})
+	}
+}
+
+func TestPool_WithOverflow(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should create transient items instead of blocking", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflow[*Worker](true),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		assert.NotSame(t, worker1, worker2)
+		assert.Equal(t, 2, itemPool.Stats().InUse)
+
+		itemPool.ReturnItem(worker2)
+		itemPool.ReturnItem(worker1)
+		assert.Equal(t, int32(1), itemPool.Count())
+		assert.Equal(t, uint64(1), itemPool.Stats().Destroyed)
+		assert.Same(t, worker1, itemPool.Borrow(ctx))
+	})
+	t.Run("should keep slots for overflowing value items", func(t *testing.T) {
+		itemPool := sync.NewPool[int](
+			sync.WithSize[int](1),
+			sync.WithOverflow[int](true),
+			sync.WithFactory[int](func() int {
+				return 1
+			}),
+		)
+		item1 := itemPool.Borrow(ctx)
+		item2 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(item1)
+		itemPool.ReturnItem(item2)
+		assert.Equal(t, int32(1), itemPool.Count())
+		_, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+	})
+	t.Run("should keep the slot of the pool when the hook panics on an overflow item", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflow[*Worker](true),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithOnBorrow[*Worker](func(w *Worker) {
+				if w.id == 0 {
+					panic("bad worker")
+				}
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: 1}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 0}
+		})
+		assert.Panics(t, func() {
+			itemPool.Borrow(ctx)
+		})
+		assert.Equal(t, 1, itemPool.Stats().InUse)
+		assert.Equal(t, int32(1), itemPool.Count())
+
+		// the only slot is still held, so the next item overflows again
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 1}
+		})
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		assert.Equal(t, int32(1), itemPool.Count())
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_WithMaxUses(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should recycle items after max uses", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithMaxUses[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		assert.Same(t, worker, itemPool.Borrow(ctx))
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, int32(0), itemPool.Count())
+
+		replacement := itemPool.Borrow(ctx)
+		assert.NotSame(t, worker, replacement)
+		assert.Equal(t, uint64(2), itemPool.Stats().Created)
+		itemPool.ReturnItem(replacement)
+	})
+	t.Run("should reject negative max uses", func(t *testing.T) {
+		_, err := sync.NewPoolErr[*Worker](sync.WithMaxUses[*Worker](-1))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+}
+
+func TestPool_ReturnItemErr(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should drop nil items without freeing a slot", func(t *testing.T) {
+		var out syncBuffer
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			captureLog[*Worker](&out),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.ErrorIs(t, itemPool.ReturnItemErr(nil), sync.ErrNilItem)
+		assert.Contains(t, out.String(), "sync: returned item is nil")
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+
+		assert.NoError(t, itemPool.ReturnItemErr(worker))
+		assert.Same(t, worker, itemPool.Borrow(ctx))
+	})
+	t.Run("should not pool nil items created by the factory", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return nil
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.NoError(t, itemPool.ReturnItemErr(worker))
+		assert.Equal(t, int32(0), itemPool.Count())
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+	})
+	t.Run("should report double returns", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.NoError(t, itemPool.ReturnItemErr(worker))
+		assert.ErrorIs(t, itemPool.ReturnItemErr(worker), sync.ErrNotBorrowed)
+	})
+}
+
+func TestPool_WithReturnPolicy(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should keep, reset or discard returned items", func(t *testing.T) {
+		var resets atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithResetFunc[*Worker](func(w *Worker) {
+				resets.Add(1)
+			}),
+			sync.WithReturnPolicy[*Worker](func(w *Worker) sync.ReturnAction {
+				return sync.ReturnAction(w.id)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: int(sync.Keep)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, int32(0), resets.Load())
+
+		worker = itemPool.Borrow(ctx)
+		worker.id = int(sync.Reset)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, int32(1), resets.Load())
+		assert.Equal(t, int32(1), itemPool.Count())
+
+		worker = itemPool.Borrow(ctx)
+		worker.id = int(sync.Discard)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, int32(0), itemPool.Count())
+		_, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+	})
+	t.Run("should not ask the policy about unhealthy items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithValidateOnReturn[*Worker](func(w *Worker) bool {
+				return false
+			}),
+			sync.WithReturnPolicy[*Worker](func(w *Worker) sync.ReturnAction {
+				t.Error("policy called for an unhealthy item")
+				return sync.Keep
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		assert.Equal(t, int32(0), itemPool.Count())
+	})
+}
+
+func TestPool_WithBulkFactory(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should create bootstrap and warmup items in bulk", func(t *testing.T) {
+		var calls []int
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](6),
+			sync.WithBootstrapItems[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithBulkFactory[*Worker](func(n int) []*Worker {
+				calls = append(calls, n)
+				workers := make([]Worker, n)
+				items := make([]*Worker, n)
+				for i := range workers {
+					items[i] = &workers[i]
+				}
+				return items
+			}),
+		)
+		assert.NoError(t, itemPool.Warmup(ctx, 10))
+		assert.Equal(t, []int{2, 4}, calls)
+		assert.Equal(t, int32(6), itemPool.Count())
+	})
+	t.Run("should fall back to the factory for borrows", func(t *testing.T) {
+		var bulk atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithBulkFactory[*Worker](func(n int) []*Worker {
+				bulk.Add(1)
+				return make([]*Worker, n)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.NotNil(t, worker)
+		assert.Equal(t, int32(0), bulk.Load())
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should borrow single items from the bulk factory", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithBulkFactory[*Worker](func(n int) []*Worker {
+				return []*Worker{{id: n}}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.Equal(t, 1, worker.id)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should fall back to the factory for bulk creation", func(t *testing.T) {
+		var calls atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithFactory[*Worker](func() *Worker {
+				calls.Add(1)
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Equal(t, int32(3), calls.Load())
+		assert.Equal(t, int32(3), itemPool.Count())
+	})
+}
+
+func TestPool_BorrowNew(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should tell new items from reused ones", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, created, err := itemPool.BorrowNew(ctx)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		itemPool.ReturnItem(worker)
+
+		reused, created, err := itemPool.BorrowNew(ctx)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Same(t, worker, reused)
+		itemPool.ReturnItem(reused)
+	})
+	t.Run("should count bootstrap items as new on their first borrow", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithBootstrapItems[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, created, err := itemPool.BorrowNew(ctx)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		itemPool.ReturnItem(worker)
+
+		reused, created, err := itemPool.BorrowNew(ctx)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Same(t, worker, reused)
+		itemPool.ReturnItem(reused)
+		assert.Equal(t, uint64(1), itemPool.Stats().Created)
+	})
+	t.Run("should count refreshed items as new", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithValidateOnBorrow[*Worker](func(w *Worker) bool {
+				return false
+			}),
+			sync.WithRefreshFunc[*Worker](func(w *Worker) error {
+				return nil
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		refreshed, created, err := itemPool.BorrowNew(ctx)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Same(t, worker, refreshed)
+		itemPool.ReturnItem(refreshed)
+	})
+}
+
+func TestPool_WithFactoryRetry(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should retry transient factory errors", func(t *testing.T) {
+		var backoffs []int
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactoryRetry[*Worker](3, func(attempt int) time.Duration {
+				backoffs = append(backoffs, attempt)
+				return time.Millisecond
+			}),
+		)
+		calls := 0
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			if calls++; calls < 3 {
+				return nil, errors.New("connection refused")
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []int{1, 2}, backoffs)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should give up after the last attempt", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactoryRetry[*Worker](2, nil),
+		)
+		calls := 0
+		refused := errors.New("connection refused")
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			calls++
+			return nil, refused
+		})
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, 3, calls)
+
+		// the slot was released
+		_, err = itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, refused)
+	})
+	t.Run("should stop retrying when the borrow is done", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactoryRetry[*Worker](10, func(attempt int) time.Duration {
+				return time.Second
+			}),
+		)
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			return nil, errors.New("connection refused")
+		})
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := itemPool.BorrowErr(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestPool_Discard(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should destroy the item and free its slot", func(t *testing.T) {
+		var resets atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithResetFunc[*Worker](func(w *Worker) {
+				resets.Add(1)
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.Discard(worker)
+		assert.Equal(t, int32(0), itemPool.Count())
+		assert.Equal(t, int32(0), itemPool.LiveCount())
+		assert.Equal(t, int32(0), resets.Load())
+
+		replacement, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		assert.NotSame(t, worker, replacement)
+
+		// discarding it again is a no-op
+		itemPool.Discard(worker)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnItem(replacement)
+	})
+}
+
+func TestPool_Replace(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should swap an item keeping its slot", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		replacement, err := itemPool.Replace(ctx, worker)
+		assert.NoError(t, err)
+		assert.NotSame(t, worker, replacement)
+		assert.Equal(t, int32(1), itemPool.Count())
+		assert.Equal(t, 1, itemPool.Stats().InUse)
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+
+		assert.ErrorIs(t, itemPool.ReturnItemErr(worker), sync.ErrNotBorrowed)
+		assert.NoError(t, itemPool.ReturnItemErr(replacement))
+	})
+	t.Run("should free the slot if the factory fails", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](sync.WithSize[*Worker](1))
+		refused := errors.New("connection refused")
+		fail := false
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			if fail {
+				return nil, refused
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		worker := itemPool.Borrow(ctx)
+		fail = true
+		_, err := itemPool.Replace(ctx, worker)
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, int32(0), itemPool.Count())
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+
+		fail = false
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should fail for items that are not borrowed", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		_, err := itemPool.Replace(ctx, &Worker{})
+		assert.ErrorIs(t, err, sync.ErrNotBorrowed)
+	})
+}
+
+func TestPool_WarmupConcurrent(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should create items up to parallelism at a time", func(t *testing.T) {
+		var running, peak atomic.Int32
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](12),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				running.Add(-1)
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.NoError(t, itemPool.WarmupConcurrent(ctx, 20, 3))
+		assert.Equal(t, int32(12), itemPool.Count())
+		assert.Equal(t, 12, itemPool.Stats().Idle)
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+		assert.Greater(t, peak.Load(), int32(1))
+	})
+	t.Run("should keep the items created before a failure", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+		)
+		refused := errors.New("connection refused")
+		var calls atomic.Int32
+		itemPool.SetFactoryErr(ctx, func() (interface{}, error) {
+			if calls.Add(1) > 4 {
+				return nil, refused
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		err := itemPool.WarmupConcurrent(ctx, 10, 2)
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, int32(4), itemPool.Count())
+		assert.Equal(t, 4, itemPool.Stats().Idle)
+	})
+}
+
+func TestPool_Copy(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should fail to borrow from a copy of the pool", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		// copy through reflection, go vet reports a plain copy
+		copied := reflect.New(reflect.TypeOf(itemPool).Elem())
+		copied.Elem().Set(reflect.ValueOf(itemPool).Elem())
+		copiedPool := copied.Interface().(*sync.Pool[*Worker])
+
+		_, err := copiedPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrPoolCopied)
+		assert.Panics(t, func() {
+			copiedPool.Borrow(ctx)
+		})
+
+		_, ok := copiedPool.TryBorrow()
+		assert.False(t, ok)
+		_, err = copiedPool.BorrowWeighted(ctx, 1)
+		assert.ErrorIs(t, err, sync.ErrPoolCopied)
+		_, err = copiedPool.BorrowSoft(ctx, time.Millisecond)
+		assert.ErrorIs(t, err, sync.ErrPoolCopied)
+		_, err = copiedPool.BorrowAffine(ctx, nil)
+		assert.ErrorIs(t, err, sync.ErrPoolCopied)
+		_, err = copiedPool.BorrowBatch(ctx, 1)
+		assert.ErrorIs(t, err, sync.ErrPoolCopied)
+		_, err = copiedPool.BorrowBatchPartial(ctx, 0, 1)
+		assert.ErrorIs(t, err, sync.ErrPoolCopied)
+
+		worker, err := itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should fail to use the zero value of a pool", func(t *testing.T) {
+		var itemPool sync.Pool[*Worker]
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrPoolNotCreated)
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+		_, err = itemPool.BorrowBatch(ctx, 1)
+		assert.ErrorIs(t, err, sync.ErrPoolNotCreated)
+		_, err = itemPool.BorrowBatchPartial(ctx, 0, 1)
+		assert.ErrorIs(t, err, sync.ErrPoolNotCreated)
+		assert.ErrorIs(t, itemPool.ReturnItemErr(&Worker{}), sync.ErrPoolNotCreated)
+		assert.NotPanics(t, func() {
+			itemPool.ReturnItem(&Worker{})
+		})
+	})
+	t.Run("should be reported by go vet", func(t *testing.T) {
+		goCmd, err := exec.LookPath("go")
+		if err != nil {
+			t.Skip("go command not found")
+		}
+		// copy_vet_test.go copies a pool, but is only built with vetcopy
+		out, err := exec.Command(goCmd, "vet", "-tags", "vetcopy", ".").CombinedOutput()
+		assert.Error(t, err)
+		assert.Contains(t, string(out), "copies lock value")
+	})
+}
+
+func TestPool_Idle(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should be closed right away when nothing is borrowed", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		select {
+		case <-itemPool.Idle():
+		default:
+			t.Fatal("idle channel should be closed")
+		}
+	})
+	t.Run("should be closed once every borrowed item is returned", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		idle1, idle2 := itemPool.Idle(), itemPool.Idle()
+
+		itemPool.ReturnItem(worker1)
+		select {
+		case <-idle1:
+			t.Fatal("idle channel closed with an item borrowed")
+		default:
+		}
+
+		itemPool.ReturnItem(worker2)
+		for _, idle := range []<-chan struct{}{idle1, idle2} {
+			select {
+			case <-idle:
+			case <-time.After(time.Second):
+				t.Fatal("idle channel should be closed")
+			}
+		}
+
+		// a new channel waits for the next idle moment
+		worker1 = itemPool.Borrow(ctx)
+		idle3 := itemPool.Idle()
+		go itemPool.ReturnItem(worker1)
+		select {
+		case <-idle3:
+		case <-time.After(time.Second):
+			t.Fatal("idle channel should be closed")
+		}
+	})
+}
+
+func TestPool_WithRefreshFunc(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should refresh an expired item in place", func(t *testing.T) {
+		refreshed := 0
+		itemPool := sync.NewPool[*Worker](
+			sync.WithMaxLifetime[*Worker](10*time.Millisecond),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithRefreshFunc[*Worker](func(w *Worker) error {
+				refreshed++
+				return nil
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		time.Sleep(20 * time.Millisecond)
+
+		assert.Same(t, worker, itemPool.Borrow(ctx))
+		assert.Equal(t, 1, refreshed)
+		stats := itemPool.Stats()
+		assert.Equal(t, uint64(1), stats.Created)
+		assert.Equal(t, uint64(0), stats.Destroyed)
+	})
+	t.Run("should create an item when refreshing fails", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithMaxLifetime[*Worker](10*time.Millisecond),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithRefreshFunc[*Worker](func(w *Worker) error {
+				return errors.New("reconnect failed")
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		time.Sleep(20 * time.Millisecond)
+
+		assert.NotSame(t, worker, itemPool.Borrow(ctx))
+		stats := itemPool.Stats()
+		assert.Equal(t, uint64(2), stats.Created)
+		assert.Equal(t, uint64(1), stats.Destroyed)
+	})
+}
+
+func TestPool_Derive(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should configure a pool of its own like the template", func(t *testing.T) {
+		template := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithResetFunc[*Worker](func(w *Worker) {
+				w.id = 0
+			}),
+		)
+		template.SetTypedFactory(ctx, func() *Worker {
+			return &Worker{id: 1 + rand.Intn(1000)}
+		})
+		derived := template.Derive(sync.WithSize[*Worker](1))
+		assert.Equal(t, 1, derived.Stats().MaxSize)
+		assert.Equal(t, 2, template.Stats().MaxSize)
+
+		worker := derived.Borrow(ctx)
+		assert.NotZero(t, worker.id)
+		_, ok := derived.TryBorrow()
+		assert.False(t, ok)
+		derived.ReturnItem(worker)
+		assert.Zero(t, worker.id)
+
+		// the template is untouched
+		stats := template.Stats()
+		assert.Equal(t, uint64(0), stats.Created)
+		assert.Equal(t, 0, stats.Idle)
+		workers, err := template.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+		assert.NotContains(t, workers, worker)
+	})
+	t.Run("should not share the store of the template", func(t *testing.T) {
+		store := sync.NewSliceStore[*Worker]()
+		template := sync.NewPool[*Worker](
+			sync.WithStore[*Worker](store),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		derived := template.Derive()
+		derived.ReturnItem(derived.Borrow(ctx))
+		assert.Equal(t, 0, store.Len())
+		assert.Equal(t, 1, derived.Stats().Idle)
+	})
+	t.Run("should not be changed by the caller reusing its options", func(t *testing.T) {
+		opts := []sync.PoolOption[*Worker]{sync.WithSize[*Worker](2)}
+		template := sync.NewPool[*Worker](opts...)
+		opts[0] = sync.WithSize[*Worker](5)
+		assert.Equal(t, 2, template.Derive().Stats().MaxSize)
+	})
+	t.Run("should reject invalid overrides", func(t *testing.T) {
+		template := sync.NewPool[*Worker](sync.WithSize[*Worker](2))
+		assert.Panics(t, func() {
+			template.Derive(sync.WithMaxIdle[*Worker](3))
+		})
+	})
 }
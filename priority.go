@@ -0,0 +1,114 @@
+package sync
+
+import (
+	"container/heap"
+	"context"
+)
+
+// BorrowPriority obtains an item from the pool like BorrowErr, but while
+// the pool is full, waiters with a higher prio are handed a slot before
+// waiters with a lower one. Waiters of the same prio are served in order
+// of arrival, and Borrow and BorrowErr wait with prio 0.
+//
+// Priorities are strict: as long as higher priority waiters keep arriving,
+// lower priority ones are never served and can starve. If that is a
+// concern, age the priority of a borrower that may wait for long, e.g. by
+// retrying with a short timeout and a higher prio each time.
+//
+// Borrowers that were already waiting before the first priority waiter
+// showed up are still served first.
+func (p *Pool[T]) BorrowPriority(ctx context.Context, prio int) (T, error) {
+	return p.borrowItem(ctx, borrowReq{prio: prio})
+}
+
+// waiter is a borrower queued for a slot by priority.
+type waiter struct {
+	prio  int
+	seq   uint64
+	index int // index in the queue, -1 once handed a slot
+	ready chan struct{}
+}
+
+// waitQueue is a heap of waiters, highest priority and earliest first.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].prio != q[j].prio {
+		return q[i].prio > q[j].prio
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
+
+// waitTurn queues for a slot with the given priority until one is handed
+// over or ctx is done.
+func (p *Pool[T]) waitTurn(ctx context.Context, prio int) error {
+	w := &waiter{prio: prio, ready: make(chan struct{})}
+	p.queueMu.Lock()
+	p.queueSeq++
+	w.seq = p.queueSeq
+	heap.Push(&p.queue, w)
+	p.queued.Add(1)
+	p.handOff()
+	p.queueMu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	p.queueMu.Lock()
+	handed := w.index < 0
+	if !handed {
+		heap.Remove(&p.queue, w.index)
+		p.queued.Add(-1)
+	}
+	p.queueMu.Unlock()
+	if handed {
+		// a slot was handed over in the meantime, pass it on
+		p.release(1)
+	}
+	return ctx.Err()
+}
+
+// wakeQueue hands free slots to queued waiters, if there are any.
+func (p *Pool[T]) wakeQueue() {
+	if p.queued.Load() == 0 {
+		return
+	}
+	p.queueMu.Lock()
+	p.handOff()
+	p.queueMu.Unlock()
+}
+
+// handOff takes free slots from the semaphore for the waiters first in
+// line. queueMu must be held.
+func (p *Pool[T]) handOff() {
+	for p.queue.Len() > 0 && p.semMax.TryAcquire(1) {
+		w := heap.Pop(&p.queue).(*waiter)
+		p.queued.Add(-1)
+		close(w.ready)
+	}
+}
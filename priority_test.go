@@ -0,0 +1,62 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPool_BorrowPriority(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should serve higher priority waiters first", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, err := itemPool.BorrowPriority(ctx, 0)
+		assert.NoError(t, err)
+
+		served := make(chan int, 3)
+		for i, prio := range []int{1, 5, 3} {
+			prio := prio
+			go func() {
+				w, err := itemPool.BorrowPriority(ctx, prio)
+				assert.NoError(t, err)
+				served <- prio
+				itemPool.ReturnItem(w)
+			}()
+			assert.Eventually(t, func() bool {
+				return itemPool.Waiters() == i+1
+			}, time.Second, time.Millisecond)
+		}
+
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 5, <-served)
+		assert.Equal(t, 3, <-served)
+		assert.Equal(t, 1, <-served)
+	})
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := itemPool.BorrowPriority(timeout, 1)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 0, itemPool.Waiters())
+
+		itemPool.ReturnItem(worker)
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker)
+	})
+}
@@ -0,0 +1,67 @@
+// Package promsync exports go-sync pool metrics to Prometheus.
+//
+// It lives in its own module so that the core pool does not depend on
+// the Prometheus client.
+package promsync
+
+import (
+	"github.com/kushsharma/go-sync"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsProvider is implemented by every sync.Pool.
+type StatsProvider interface {
+	Stats() sync.Stats
+}
+
+// Collector is a prometheus.Collector reading the stats of a pool on
+// every scrape.
+type Collector struct {
+	pool StatsProvider
+
+	idle      *prometheus.Desc
+	inUse     *prometheus.Desc
+	waiting   *prometheus.Desc
+	created   *prometheus.Desc
+	destroyed *prometheus.Desc
+	maxSize   *prometheus.Desc
+}
+
+// NewCollector creates a Collector for pool, labelling all metrics
+// with the given pool name.
+func NewCollector(name string, pool StatsProvider) *Collector {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("go_sync", "pool", metric), help, nil, labels)
+	}
+	return &Collector{
+		pool:      pool,
+		idle:      desc("idle_items", "Number of items waiting in the pool to be borrowed."),
+		inUse:     desc("in_use_items", "Number of items currently borrowed."),
+		waiting:   desc("waiting_borrowers", "Number of borrowers blocked on a full pool."),
+		created:   desc("created_items_total", "Total number of items created by the factory."),
+		destroyed: desc("destroyed_items_total", "Total number of items that left the pool for good."),
+		maxSize:   desc("max_items", "Max number of items in the pool, 0 if unbounded."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.idle
+	ch <- c.inUse
+	ch <- c.waiting
+	ch <- c.created
+	ch <- c.destroyed
+	ch <- c.maxSize
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.waiting, prometheus.GaugeValue, float64(stats.Waiting))
+	ch <- prometheus.MustNewConstMetric(c.created, prometheus.CounterValue, float64(stats.Created))
+	ch <- prometheus.MustNewConstMetric(c.destroyed, prometheus.CounterValue, float64(stats.Destroyed))
+	ch <- prometheus.MustNewConstMetric(c.maxSize, prometheus.GaugeValue, float64(stats.MaxSize))
+}
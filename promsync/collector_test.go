@@ -0,0 +1,46 @@
+package promsync_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kushsharma/go-sync"
+	"github.com/kushsharma/go-sync/promsync"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type Worker struct {
+	id int
+}
+
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should export current pool stats", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{}
+		})
+		worker := itemPool.Borrow(ctx)
+		defer itemPool.ReturnItem(worker)
+
+		registry := prometheus.NewPedanticRegistry()
+		assert.NoError(t, registry.Register(promsync.NewCollector("workers", itemPool)))
+
+		expected := `
+# HELP go_sync_pool_in_use_items Number of items currently borrowed.
+# TYPE go_sync_pool_in_use_items gauge
+go_sync_pool_in_use_items{pool="workers"} 1
+# HELP go_sync_pool_max_items Max number of items in the pool, 0 if unbounded.
+# TYPE go_sync_pool_max_items gauge
+go_sync_pool_max_items{pool="workers"} 3
+`
+		err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+			"go_sync_pool_in_use_items", "go_sync_pool_max_items")
+		assert.NoError(t, err)
+	})
+}
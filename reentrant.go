@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// WithReentrancyGuard makes a borrow on a full pool fail with
+// ErrReentrantBorrow when the borrowing goroutine already holds an item of
+// the pool, instead of waiting for a slot it may be holding itself. This
+// catches layered code borrowing twice from the same pool, which deadlocks
+// once the pool is full.
+//
+// Telling goroutines apart adds to the cost of every borrow, so the guard
+// is off by default and meant for development builds. Only items tracked
+// one by one are guarded, see NewPointerPool.
+func WithReentrancyGuard[T any]() PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.guard = &reentrancyGuard{holders: make(map[uint64]int)}
+	}
+}
+
+// reentrancyGuard counts the items each goroutine is holding.
+type reentrancyGuard struct {
+	mu      sync.Mutex
+	holders map[uint64]int
+}
+
+// enter records the current goroutine borrowing an item and returns its id.
+func (g *reentrancyGuard) enter() uint64 {
+	id := goroutineID()
+	g.mu.Lock()
+	g.holders[id]++
+	g.mu.Unlock()
+	return id
+}
+
+// leave records goroutine id giving back an item it borrowed.
+func (g *reentrancyGuard) leave(id uint64) {
+	g.mu.Lock()
+	if g.holders[id]--; g.holders[id] <= 0 {
+		delete(g.holders, id)
+	}
+	g.mu.Unlock()
+}
+
+// holding reports whether the current goroutine holds an item.
+func (g *reentrancyGuard) holding() bool {
+	id := goroutineID()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.holders[id] > 0
+}
+
+// goroutineID returns the id of the current goroutine, parsed from the
+// header of its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
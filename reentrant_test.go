@@ -0,0 +1,77 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPool_WithReentrancyGuard(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should fail a reentrant borrow on a full pool", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithReentrancyGuard[*Worker](),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrReentrantBorrow)
+
+		itemPool.ReturnItem(worker)
+		worker, err = itemPool.BorrowErr(ctx)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+	})
+	t.Run("should let other goroutines wait", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithReentrancyGuard[*Worker](),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		done := make(chan error)
+		go func() {
+			worker, err := itemPool.BorrowErr(ctx)
+			if err == nil {
+				itemPool.ReturnItem(worker)
+			}
+			done <- err
+		}()
+		assert.Eventually(t, func() bool {
+			return itemPool.Waiters() == 1
+		}, time.Second, time.Millisecond)
+		itemPool.ReturnItem(worker)
+		assert.NoError(t, <-done)
+	})
+	t.Run("should forget items returned by other goroutines", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithReentrancyGuard[*Worker](),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		held := make(chan *Worker)
+		go func() {
+			itemPool.ReturnItem(worker)
+			held <- itemPool.Borrow(ctx)
+		}()
+		worker = <-held
+
+		// the pool is full, but not because of this goroutine
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := itemPool.BorrowErr(timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		itemPool.ReturnItem(worker)
+	})
+}
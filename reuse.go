@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"context"
+	"time"
+)
+
+// reuseWait is how long a borrow waits for a returned item when the pool
+// falls short of its WithMinReuse ratio.
+const reuseWait = 10 * time.Millisecond
+
+// WithMinReuse caps the pressure on the factory by aiming for at least
+// ratio of the borrows to be served with an idle item, e.g. 0.9 for at
+// most one in ten borrows calling the factory. While the pool falls short
+// of ratio, a borrow finding no idle item waits briefly for a borrowed
+// item to be returned before calling the factory. WithMinReuse implies
+// Deterministic retention, so that idle items are not dropped by the
+// garbage collector. The achieved ratio is reported by Stats.
+func WithMinReuse[T any](ratio float64) PoolOption[T] {
+	return func(p *Pool[T]) {
+		if ratio < 0 || ratio > 1 {
+			p.invalid("min reuse must be between 0 and 1, got %g", ratio)
+			return
+		}
+		p.minReuse = ratio
+	}
+}
+
+// awaitReuse waits briefly for an idle item if the pool falls short of
+// its min reuse ratio and items are borrowed which may be returned.
+func (p *Pool[T]) awaitReuse(ctx context.Context) (*entry[T], bool) {
+	if p.minReuse == 0 || p.inUse.Load() == 0 {
+		return nil, false
+	}
+	if reuseRatio(p.hits.Load(), p.misses.Load()) >= p.minReuse {
+		return nil, false
+	}
+	return p.awaitIdle(ctx, reuseWait)
+}
+
+// reuseRatio returns the fraction of borrows served with an idle item, 0
+// before the first borrow.
+func reuseRatio(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
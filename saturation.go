@@ -0,0 +1,42 @@
+package sync
+
+import "sync/atomic"
+
+// WithSaturationCallback calls onSaturate when all the items a bounded
+// pool may hold are borrowed, and onRecover once fewer than three quarters
+// of them are borrowed again, so that alerts don't flap while the pool
+// hovers at its limit. Items borrowed with BorrowWeighted count with their
+// weight, so that a pool is saturated once its whole size is borrowed.
+// Each call of onSaturate is followed by one of onRecover before it is
+// called again. Either function may be nil.
+//
+// The callbacks run on the goroutine whose borrow or return crossed the
+// threshold, without holding any lock of the pool, so they may do I/O such
+// as sending an alert; that borrow or return waits for them though.
+func WithSaturationCallback[T any](onSaturate, onRecover func()) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.saturation = &saturation{onSaturate: onSaturate, onRecover: onRecover}
+	}
+}
+
+// saturation tracks whether a pool is saturated.
+type saturation struct {
+	onSaturate func()
+	onRecover  func()
+	held       atomic.Int64 // held is the total weight of the borrowed items
+	saturated  atomic.Bool
+}
+
+// raised reports a borrow of w slots from a pool of size.
+func (s *saturation) raised(w int64, size int32) {
+	if held := s.held.Add(w); size > 0 && held >= int64(size) && s.saturated.CompareAndSwap(false, true) && s.onSaturate != nil {
+		s.onSaturate()
+	}
+}
+
+// lowered reports a return of w slots to a pool of size.
+func (s *saturation) lowered(w int64, size int32) {
+	if held := s.held.Add(-w); held < int64(size-size/4) && s.saturated.CompareAndSwap(true, false) && s.onRecover != nil {
+		s.onRecover()
+	}
+}
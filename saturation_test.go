@@ -0,0 +1,81 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestPool_WithSaturationCallback(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should report saturation and recovery once", func(t *testing.T) {
+		var events []string
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithSaturationCallback[*Worker](func() {
+				events = append(events, "saturated")
+			}, func() {
+				events = append(events, "recovered")
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+
+		worker := itemPool.Borrow(ctx)
+		assert.Equal(t, []string{"saturated"}, events)
+
+		// hovering at the limit does not flap
+		itemPool.ReturnItem(worker)
+		worker = itemPool.Borrow(ctx)
+		assert.Equal(t, []string{"saturated"}, events)
+
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(workers[0])
+		assert.Equal(t, []string{"saturated", "recovered"}, events)
+		itemPool.ReturnBatch(workers[1:])
+		assert.Equal(t, []string{"saturated", "recovered"}, events)
+	})
+	t.Run("should count the weight of borrowed items", func(t *testing.T) {
+		var events []string
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](8),
+			sync.WithSaturationCallback[*Worker](func() {
+				events = append(events, "saturated")
+			}, func() {
+				events = append(events, "recovered")
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, err := itemPool.BorrowWeighted(ctx, 8)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"saturated"}, events)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, []string{"saturated", "recovered"}, events)
+
+		big, err := itemPool.BorrowWeighted(ctx, 6)
+		assert.NoError(t, err)
+		small := itemPool.Borrow(ctx)
+		assert.Equal(t, []string{"saturated", "recovered"}, events)
+		itemPool.ReturnItem(small)
+		itemPool.ReturnItem(big)
+	})
+	t.Run("should ignore unbounded pools", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSaturationCallback[*Worker](func() {
+				t.Error("unbounded pool saturated")
+			}, nil),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+	})
+}
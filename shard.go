@@ -0,0 +1,165 @@
+package sync
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// WithShards splits the bookkeeping of borrowed items, the idle items of a
+// Deterministic pool and the free slots of a bounded pool across n shards,
+// so that borrowers on different cores don't contend for a single lock. If
+// n is 0 or less, GOMAXPROCS shards are used.
+//
+// Idle items and free slots are spread over the shards round-robin, and
+// every borrow is given a home shard the same way. A borrow finding its
+// home shard empty steals from the others. Free slots are only cached by
+// the shards while no borrower waits for one. The max size of the pool and
+// its Stats still cover all shards, and FIFO ordering is only kept within
+// each shard. Ephemeral pools keep their idle items per core already.
+func WithShards[T any](n int) PoolOption[T] {
+	return func(p *Pool[T]) {
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		p.shards = n
+	}
+}
+
+// StealCount returns the number of borrows which found their home shard
+// without idle items and took one from another shard, 0 for a pool without
+// shards. A high count compared to Hits hints at more shards than the pool
+// keeps idle items for.
+func (p *Pool[T]) StealCount() uint64 {
+	if s, ok := p.idle.(*shardedStore[T]); ok {
+		return s.steals.Load()
+	}
+	return 0
+}
+
+// shardedStore spreads idle items across several slice stores.
+type shardedStore[T any] struct {
+	shards []*SliceStore[T]
+	next   atomic.Uint32 // next picks the shard an item is put into
+	home   atomic.Uint32 // home picks the home shard of a borrow
+	steals atomic.Uint64 // steals counts items taken from another shard than the home one
+}
+
+func newShardedStore[T any](n int, ordering Ordering) *shardedStore[T] {
+	s := &shardedStore[T]{shards: make([]*SliceStore[T], n)}
+	for i := range s.shards {
+		s.shards[i] = newSliceStore[T](ordering)
+	}
+	return s
+}
+
+func (s *shardedStore[T]) get() (*entry[T], bool) {
+	// lock one shard at a time while stealing, so that borrows never
+	// wait for each other's locks in a different order
+	home := int(s.home.Add(1) % uint32(len(s.shards)))
+	for i := 0; i < len(s.shards); i++ {
+		if e, ok := s.shards[(home+i)%len(s.shards)].get(); ok {
+			if i > 0 {
+				s.steals.Add(1)
+			}
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+func (s *shardedStore[T]) put(e *entry[T]) {
+	s.shards[s.next.Add(1)%uint32(len(s.shards))].put(e)
+}
+
+func (s *shardedStore[T]) expire(cutoff time.Time) ([]*entry[T], time.Time) {
+	var expired []*entry[T]
+	var oldest time.Time
+	for _, shard := range s.shards {
+		e, o := shard.expire(cutoff)
+		expired = append(expired, e...)
+		if !o.IsZero() && (oldest.IsZero() || o.Before(oldest)) {
+			oldest = o
+		}
+	}
+	return expired, oldest
+}
+
+func (s *shardedStore[T]) items() []T {
+	var items []T
+	for _, shard := range s.shards {
+		items = append(items, shard.items()...)
+	}
+	return items
+}
+
+// slotShards caches the free slots of a bounded pool with shards, so that
+// borrows mostly take and give back a slot without locking the semaphore.
+type slotShards struct {
+	shards []slotShard
+	next   atomic.Uint32 // next picks the shard a slot is cached in
+	home   atomic.Uint32 // home picks the home shard of a borrow
+}
+
+// slotShard counts the free slots cached by a shard.
+type slotShard struct {
+	free atomic.Int64
+	_    [56]byte // keep shards on cache lines of their own
+}
+
+func newSlotShards(n int) *slotShards {
+	return &slotShards{shards: make([]slotShard, n)}
+}
+
+// take takes a cached slot, from the home shard of the borrow if it has
+// one.
+func (s *slotShards) take() bool {
+	home := int(s.home.Add(1) % uint32(len(s.shards)))
+	for i := 0; i < len(s.shards); i++ {
+		free := &s.shards[(home+i)%len(s.shards)].free
+		for n := free.Load(); n > 0; n = free.Load() {
+			if free.CompareAndSwap(n, n-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// put caches a free slot.
+func (s *slotShards) put() {
+	s.shards[s.next.Add(1)%uint32(len(s.shards))].free.Add(1)
+}
+
+// flush takes all cached slots out of the shards and returns their number.
+func (s *slotShards) flush() int64 {
+	var n int64
+	for i := range s.shards {
+		n += s.shards[i].free.Swap(0)
+	}
+	return n
+}
+
+// takeSlots takes n free slots without waiting, a single one from the
+// shards first.
+func (p *Pool[T]) takeSlots(n int64) bool {
+	if n == 1 && p.slots != nil && p.slots.take() {
+		return true
+	}
+	return p.semMax.TryAcquire(n)
+}
+
+// trySlots is takeSlots, but never takes slots ahead of queued borrowers.
+func (p *Pool[T]) trySlots(n int64) bool {
+	return p.queued.Load() == 0 && p.takeSlots(n)
+}
+
+// flushSlots hands the slots cached by the shards back to the semaphore.
+func (p *Pool[T]) flushSlots() {
+	if p.slots == nil {
+		return
+	}
+	if n := p.slots.flush(); n > 0 {
+		p.releaseShared(n)
+	}
+}
@@ -0,0 +1,180 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	stdsync "sync"
+	"testing"
+	"time"
+)
+
+func TestPool_WithShards(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should reuse idle items across shards", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithShards[*Worker](4),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 4)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+		assert.Equal(t, 4, itemPool.Stats().Idle)
+
+		for i := 0; i < 8; i++ {
+			worker := itemPool.Borrow(ctx)
+			assert.Contains(t, workers, worker)
+			itemPool.ReturnItem(worker)
+		}
+		stats := itemPool.Stats()
+		assert.Equal(t, uint64(4), stats.Created)
+		assert.Equal(t, 4, stats.Idle)
+	})
+	t.Run("should count items stolen from other shards", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithShards[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker1)
+		itemPool.ReturnItem(worker2)
+
+		// borrows take turns at being home to either shard, like the
+		// returned items, so each finds an item at home
+		assert.Same(t, worker1, itemPool.Borrow(ctx))
+		assert.Same(t, worker2, itemPool.Borrow(ctx))
+		assert.Equal(t, uint64(0), itemPool.StealCount())
+
+		// a third item makes the next borrow's home the other shard
+		worker3 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker1)
+		assert.Same(t, worker1, itemPool.Borrow(ctx))
+		assert.Equal(t, uint64(1), itemPool.StealCount())
+		assert.Equal(t, uint64(3), itemPool.Stats().Created)
+		itemPool.ReturnItem(worker1)
+		itemPool.ReturnItem(worker2)
+		itemPool.ReturnItem(worker3)
+	})
+	t.Run("should keep the size of the pool across shards", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithShards[*Worker](4),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 3)
+		assert.NoError(t, err)
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+		itemPool.ReturnBatch(workers)
+
+		// the returned slots are cached by the shards
+		for i := 0; i < 3; i++ {
+			_, ok = itemPool.TryBorrow()
+			assert.True(t, ok)
+		}
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+	})
+	t.Run("should hand cached slots to waiting borrowers", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithShards[*Worker](4),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		var wg stdsync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					worker, err := itemPool.BorrowTimeout(time.Second)
+					if !assert.NoError(t, err) {
+						return
+					}
+					itemPool.ReturnItem(worker)
+				}
+			}()
+		}
+		wg.Wait()
+		workers, err := itemPool.BorrowBatch(ctx, 2)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers)
+	})
+	t.Run("should take cached slots back when shrinking", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](3),
+			sync.WithShards[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		for i := 0; i < 3; i++ {
+			itemPool.ReturnItem(itemPool.Borrow(ctx))
+		}
+		itemPool.Resize(1)
+		_, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		_, ok = itemPool.TryBorrow()
+		assert.False(t, ok)
+	})
+	t.Run("should not count steals without shards", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		itemPool.Borrow(ctx)
+		assert.Equal(t, uint64(0), itemPool.StealCount())
+	})
+}
+
+func BenchmarkPool_Shards(b *testing.B) {
+	ctx := context.Background()
+	for _, bench := range []struct {
+		name   string
+		shards int
+		size   int
+	}{
+		{name: "single", shards: 1},
+		{name: "sharded", shards: 0},
+		{name: "bounded single", shards: 1, size: 1024},
+		{name: "bounded sharded", shards: 0, size: 1024},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			opts := []sync.PoolOption[*Worker]{
+				sync.WithShards[*Worker](bench.shards),
+				sync.WithRetentionMode[*Worker](sync.Deterministic),
+				sync.WithFactory[*Worker](func() *Worker {
+					return &Worker{id: rand.Intn(1000)}
+				}),
+			}
+			if bench.size > 0 {
+				opts = append(opts, sync.WithSize[*Worker](bench.size))
+			}
+			itemPool := sync.NewPool[*Worker](opts...)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					itemPool.ReturnItem(itemPool.Borrow(ctx))
+				}
+			})
+		})
+	}
+}
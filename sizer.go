@@ -0,0 +1,54 @@
+package sync
+
+// WithSizer sets a function reporting how many bytes an item takes, so
+// that the pool can track the bytes its idle items retain, see
+// RetainedBytes and WithMaxBytes. It must report the same size for an
+// item for as long as the item is idle.
+func WithSizer[T any](fn func(T) int64) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.sizer = fn
+	}
+}
+
+// WithMaxBytes limits the bytes retained by idle items to n, as measured
+// by the WithSizer function, e.g. for pools of variable-sized buffers.
+// Items returned while keeping them would exceed n are destroyed instead
+// of being put back. Borrowed items don't count against n.
+func WithMaxBytes[T any](n int64) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.maxBytes = n
+	}
+}
+
+// RetainedBytes returns the bytes retained by the idle items of the pool,
+// as measured by the WithSizer function, 0 without one. With Ephemeral
+// retention, idle items dropped by the garbage collector are discounted
+// once the collector has run their finalizers.
+func (p *Pool[T]) RetainedBytes() int64 {
+	return p.retained.Load()
+}
+
+// retain counts the bytes of an item about to become idle, reporting false
+// if they don't fit within the max bytes.
+func (p *Pool[T]) retain(e *entry[T]) bool {
+	if p.sizer == nil {
+		return true
+	}
+	n := p.sizer(e.item)
+	for {
+		retained := p.retained.Load()
+		if p.maxBytes > 0 && retained+n > p.maxBytes {
+			return false
+		}
+		if p.retained.CompareAndSwap(retained, retained+n) {
+			return true
+		}
+	}
+}
+
+// unretain discounts the bytes of an item which is no longer idle.
+func (p *Pool[T]) unretain(e *entry[T]) {
+	if p.sizer != nil && !e.tier {
+		p.retained.Add(-p.sizer(e.item))
+	}
+}
@@ -0,0 +1,58 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPool_WithMaxBytes(t *testing.T) {
+	ctx := context.Background()
+	newPool := func(opts ...sync.PoolOption[*[]byte]) *sync.Pool[*[]byte] {
+		return sync.NewPool[*[]byte](append([]sync.PoolOption[*[]byte]{
+			sync.WithSize[*[]byte](4),
+			sync.WithRetentionMode[*[]byte](sync.Deterministic),
+			sync.WithSizer[*[]byte](func(buf *[]byte) int64 {
+				return int64(cap(*buf))
+			}),
+			sync.WithFactory[*[]byte](func() *[]byte {
+				buf := make([]byte, 0, 10)
+				return &buf
+			}),
+		}, opts...)...)
+	}
+	t.Run("should track the bytes retained by idle items", func(t *testing.T) {
+		itemPool := newPool()
+		buf1 := itemPool.Borrow(ctx)
+		buf2 := itemPool.Borrow(ctx)
+		assert.Equal(t, int64(0), itemPool.RetainedBytes())
+
+		itemPool.ReturnItem(buf1)
+		itemPool.ReturnItem(buf2)
+		assert.Equal(t, int64(20), itemPool.RetainedBytes())
+
+		itemPool.Borrow(ctx)
+		assert.Equal(t, int64(10), itemPool.RetainedBytes())
+		assert.NoError(t, itemPool.Close())
+		assert.Equal(t, int64(0), itemPool.RetainedBytes())
+	})
+	t.Run("should drop returned items exceeding the max bytes", func(t *testing.T) {
+		itemPool := newPool(sync.WithMaxBytes[*[]byte](100))
+		buf1 := itemPool.Borrow(ctx)
+		buf2 := itemPool.Borrow(ctx)
+		*buf1 = make([]byte, 0, 60)
+		*buf2 = make([]byte, 0, 60)
+
+		itemPool.ReturnItem(buf1)
+		itemPool.ReturnItem(buf2)
+		assert.Equal(t, int64(60), itemPool.RetainedBytes())
+		stats := itemPool.Stats()
+		assert.Equal(t, 1, stats.Idle)
+		assert.Equal(t, uint64(1), stats.Destroyed)
+	})
+	t.Run("should reject max bytes without a sizer", func(t *testing.T) {
+		_, err := sync.NewPoolErr[*[]byte](sync.WithMaxBytes[*[]byte](100))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+}
@@ -0,0 +1,42 @@
+package sync
+
+import (
+	"context"
+	"time"
+)
+
+// BorrowSoft obtains an item from the pool like BorrowErr, but prefers
+// reusing an item over creating one: once it has a slot and no item is
+// idle, it waits up to softWait for a borrowed item to be returned before
+// calling the factory. Waiting for a slot on a full pool is still bounded
+// only by ctx.
+func (p *Pool[T]) BorrowSoft(ctx context.Context, softWait time.Duration) (T, error) {
+	return p.borrowItem(ctx, borrowReq{softWait: softWait})
+}
+
+// awaitIdle takes a usable idle item out of the pool, waiting up to d for
+// one to be returned. It reports false if none became idle in time.
+func (p *Pool[T]) awaitIdle(ctx context.Context, d time.Duration) (*entry[T], bool) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		if e, ok := p.takeIdle(); ok {
+			return e, true
+		}
+		select {
+		case <-p.idleReady:
+		case <-timer.C:
+			return nil, false
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// wakeSoftWaiter wakes up a BorrowSoft waiting for an idle item.
+func (p *Pool[T]) wakeSoftWaiter() {
+	select {
+	case p.idleReady <- struct{}{}:
+	default:
+	}
+}
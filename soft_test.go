@@ -0,0 +1,61 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPool_BorrowSoft(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should reuse an item returned within the soft wait", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			itemPool.ReturnItem(worker)
+		}()
+		reused, err := itemPool.BorrowSoft(ctx, time.Second)
+		assert.NoError(t, err)
+		assert.Same(t, worker, reused)
+		assert.Equal(t, uint64(1), itemPool.Stats().Created)
+	})
+	t.Run("should create an item once the soft wait is over", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		start := time.Now()
+		created, err := itemPool.BorrowSoft(ctx, 20*time.Millisecond)
+		assert.NoError(t, err)
+		assert.NotSame(t, worker, created)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, uint64(2), itemPool.Stats().Created)
+	})
+	t.Run("should not wait with an idle item at hand", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		start := time.Now()
+		_, err := itemPool.BorrowSoft(ctx, time.Second)
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
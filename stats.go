@@ -0,0 +1,284 @@
+package sync
+
+import (
+	"expvar"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time snapshot of the state of a Pool.
+type Stats struct {
+	// Idle is the number of items waiting in the pool to be borrowed.
+	Idle int
+	// InUse is the number of items currently borrowed.
+	InUse int
+	// Waiting is the number of borrowers blocked on a full pool.
+	Waiting int
+	// Created is the total number of items created by the factory.
+	Created uint64
+	// Destroyed is the total number of items that left the pool for good.
+	Destroyed uint64
+	// MaxSize is the max number of items in the pool, 0 if unbounded.
+	MaxSize int
+	// OverflowInUse is the number of overflow tier items currently
+	// borrowed, not included in InUse.
+	OverflowInUse int
+	// OverflowIdle is the number of overflow tier items waiting to be
+	// borrowed, not included in Idle.
+	OverflowIdle int
+	// ReuseRatio is the fraction of borrows served with an idle item
+	// rather than by calling the factory, 0 before the first borrow.
+	ReuseRatio float64
+}
+
+// Stats returns a snapshot of the pool. Reading it is lock-free, and
+// InUse+Idle+OverflowInUse+OverflowIdle always adds up to the number of
+// live items at the moment of the snapshot.
+func (p *Pool[T]) Stats() Stats {
+	live := int(p.count.Load())
+	var tierInUse, tierIdle int
+	if p.tier != nil {
+		tierInUse = int(p.tier.inUse.Load())
+		tierIdle = int(p.tier.idleCount.Load())
+		if tierInUse > live {
+			tierInUse = live
+		}
+		if tierInUse+tierIdle > live {
+			// a tier item is being destroyed
+			tierIdle = live - tierInUse
+		}
+		live -= tierInUse + tierIdle
+	}
+	inUse := int(p.inUse.Load()) - tierInUse
+	if inUse < 0 {
+		// a tier item is being borrowed
+		inUse = 0
+	}
+	if inUse > live {
+		// an item is being destroyed on return
+		inUse = live
+	}
+	return Stats{
+		Idle:          live - inUse,
+		InUse:         inUse,
+		OverflowInUse: tierInUse,
+		OverflowIdle:  tierIdle,
+		Waiting:       int(p.waiting.Load()),
+		Created:       p.created.Load(),
+		Destroyed:     p.destroyed.Load(),
+		MaxSize:       int(p.size.Load()),
+		ReuseRatio:    reuseRatio(p.hits.Load(), p.misses.Load()),
+	}
+}
+
+// String summarizes the pool for logs, like
+// Pool[*Worker]{idle=3 inUse=2 max=10 waiting=0}, where max is 0 for an
+// unbounded pool. Like Stats, it takes no locks.
+func (p *Pool[T]) String() string {
+	s := p.Stats()
+	b := make([]byte, 0, 64)
+	b = append(b, "Pool["...)
+	b = append(b, reflect.TypeOf((*T)(nil)).Elem().String()...)
+	b = append(b, "]{idle="...)
+	b = strconv.AppendInt(b, int64(s.Idle), 10)
+	b = append(b, " inUse="...)
+	b = strconv.AppendInt(b, int64(s.InUse), 10)
+	b = append(b, " max="...)
+	b = strconv.AppendInt(b, int64(s.MaxSize), 10)
+	b = append(b, " waiting="...)
+	b = strconv.AppendInt(b, int64(s.Waiting), 10)
+	b = append(b, '}')
+	return string(b)
+}
+
+// Waiters returns the number of goroutines currently blocked in Borrow
+// waiting for a slot on a full pool.
+func (p *Pool[T]) Waiters() int {
+	return int(p.waiting.Load())
+}
+
+// PeakInUse returns the highest number of items borrowed at the same time
+// since the pool was created or ResetPeak was last called.
+func (p *Pool[T]) PeakInUse() int {
+	return int(p.peakInUse.Load())
+}
+
+// ResetPeak starts a new measurement interval for PeakInUse, beginning
+// from the number of items borrowed right now.
+func (p *Pool[T]) ResetPeak() {
+	p.peakInUse.Store(p.inUse.Load())
+}
+
+// IdleItems returns a copy of the items currently idle in the pool, for
+// tests and diagnostics rather than the hot path: it locks the idle items
+// while copying them. The items are not borrowed, they stay available to
+// borrowers and must neither be used nor returned. Only Deterministic
+// pools can list their idle items, IdleItems returns nil otherwise.
+func (p *Pool[T]) IdleItems() []T {
+	s, ok := p.idle.(listingStore[T])
+	if !ok {
+		return nil
+	}
+	return s.items()
+}
+
+// defaultWaitSmoothing is the smoothing factor of AvgWaitTime without
+// WithWaitSmoothing.
+const defaultWaitSmoothing = 0.1
+
+// WithWaitSmoothing sets the smoothing factor of AvgWaitTime, between 0
+// and 1, 0.1 by default. Higher factors follow recent waits more closely,
+// lower ones even out bursts.
+func WithWaitSmoothing[T any](alpha float64) PoolOption[T] {
+	return func(p *Pool[T]) {
+		if alpha <= 0 || alpha > 1 {
+			p.invalid("wait smoothing must be in (0, 1], got %v", alpha)
+			return
+		}
+		p.smoothing = alpha
+	}
+}
+
+// AvgWaitTime returns an exponential moving average of how long borrows
+// of a bounded pool waited for a slot, counting the ones served right away
+// as not waiting. Borrows which gave up waiting are left out.
+func (p *Pool[T]) AvgWaitTime() time.Duration {
+	return time.Duration(p.avgWait.Load())
+}
+
+// WithWaitBuckets keeps a histogram of how long borrows of a bounded pool
+// waited for a slot, with buckets up to each of bounds, which must be
+// ascending. Percentiles of the waits can be read off WaitHistogram.
+func WithWaitBuckets[T any](bounds []time.Duration) PoolOption[T] {
+	return func(p *Pool[T]) {
+		for i := 1; i < len(bounds); i++ {
+			if bounds[i] <= bounds[i-1] {
+				p.invalid("wait buckets must be ascending, got %v", bounds)
+				return
+			}
+		}
+		p.waitBounds = append([]time.Duration(nil), bounds...)
+		p.waitCounts = make([]atomic.Uint64, len(bounds)+1)
+	}
+}
+
+// Bucket is a bucket of a wait time histogram.
+type Bucket struct {
+	// UpperBound is the longest wait counted in the bucket, and longer
+	// than those of the previous bucket. The last bucket, counting the
+	// waits longer than every bound, has the max time.Duration.
+	UpperBound time.Duration
+	// Count is the number of waits in the bucket.
+	Count uint64
+}
+
+// WaitHistogram returns how many borrows waited for how long, counted
+// into the buckets set with WithWaitBuckets, plus the one for longer
+// waits. Like AvgWaitTime, it counts borrows which got a slot right away
+// as not waiting, and leaves out the ones which gave up. It returns nil
+// without WithWaitBuckets.
+func (p *Pool[T]) WaitHistogram() []Bucket {
+	if p.waitCounts == nil {
+		return nil
+	}
+	buckets := make([]Bucket, len(p.waitCounts))
+	for i := range buckets {
+		buckets[i].UpperBound = math.MaxInt64
+		if i < len(p.waitBounds) {
+			buckets[i].UpperBound = p.waitBounds[i]
+		}
+		buckets[i].Count = p.waitCounts[i].Load()
+	}
+	return buckets
+}
+
+// recordWait folds the wait of a borrow into the average wait time and
+// the histogram.
+func (p *Pool[T]) recordWait(d time.Duration) {
+	if p.waitCounts != nil {
+		i := sort.Search(len(p.waitBounds), func(i int) bool {
+			return d <= p.waitBounds[i]
+		})
+		p.waitCounts[i].Add(1)
+	}
+	for {
+		avg := p.avgWait.Load()
+		next := avg + int64(p.smoothing*float64(int64(d)-avg))
+		if avg == next || p.avgWait.CompareAndSwap(avg, next) {
+			return
+		}
+	}
+}
+
+// WithCreateTiming times every run of the factory, for AvgCreateTime and
+// CreateCount. Without it, the factory is not timed.
+func WithCreateTiming[T any]() PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.createTiming = true
+	}
+}
+
+// AvgCreateTime returns how long runs of the factory took on average,
+// failed ones included, 0 without WithCreateTiming. Compared with
+// AvgWaitTime and the share of Misses, it tells whether creating items
+// adds latency to borrows.
+func (p *Pool[T]) AvgCreateTime() time.Duration {
+	n := p.createCount.Load()
+	if n == 0 {
+		return 0
+	}
+	return time.Duration(p.createNanos.Load() / int64(n))
+}
+
+// CreateCount returns how often the factory ran, 0 without
+// WithCreateTiming.
+func (p *Pool[T]) CreateCount() uint64 {
+	return p.createCount.Load()
+}
+
+// recordCreate adds a run of the factory to the create timing.
+func (p *Pool[T]) recordCreate(d time.Duration) {
+	p.createNanos.Add(int64(d))
+	p.createCount.Add(1)
+}
+
+// Hits returns the number of borrows served with an idle item.
+func (p *Pool[T]) Hits() uint64 {
+	return p.hits.Load()
+}
+
+// Misses returns the number of borrows which found no usable idle item
+// and had to call the factory, whether or not it succeeded. A high ratio
+// of misses to hits on a bounded pool hints at a too small WithMaxIdle or,
+// with Ephemeral retention, idle items dropped by the garbage collector.
+func (p *Pool[T]) Misses() uint64 {
+	return p.misses.Load()
+}
+
+// raisePeak records inUse as the new high-water mark if it exceeds it,
+// and whether the w slots just borrowed saturate the pool.
+func (p *Pool[T]) raisePeak(inUse int32, w int64) {
+	if p.saturation != nil {
+		p.saturation.raised(w, p.size.Load())
+	}
+	for {
+		peak := p.peakInUse.Load()
+		if inUse <= peak || p.peakInUse.CompareAndSwap(peak, inUse) {
+			return
+		}
+	}
+}
+
+// PublishExpvar publishes the pool stats as JSON under name in the
+// expvar registry, making them visible on /debug/vars. The stats are
+// read on every scrape. Like expvar.Publish, it panics if name is
+// already registered.
+func (p *Pool[T]) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return p.Stats()
+	}))
+}
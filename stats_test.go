@@ -0,0 +1,395 @@
+package sync_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPool_Stats(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should report idle, in-use and waiting items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker1)
+		stats := itemPool.Stats()
+		assert.Equal(t, 1, stats.Idle)
+		assert.Equal(t, 1, stats.InUse)
+		assert.Equal(t, uint64(2), stats.Created)
+		assert.Equal(t, 2, stats.MaxSize)
+
+		worker1 = itemPool.Borrow(ctx)
+		done := make(chan struct{})
+		go func() {
+			itemPool.ReturnItem(itemPool.Borrow(ctx))
+			close(done)
+		}()
+		assert.Eventually(t, func() bool {
+			return itemPool.Stats().Waiting == 1
+		}, time.Second, time.Millisecond)
+
+		itemPool.ReturnItem(worker1)
+		<-done
+		assert.Equal(t, 0, itemPool.Stats().Waiting)
+		itemPool.ReturnItem(worker2)
+		assert.Equal(t, 0, itemPool.Stats().InUse)
+	})
+}
+
+func TestPool_Waiters(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should count borrowers blocked on a full pool", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		worker := itemPool.Borrow(ctx)
+		assert.Equal(t, 0, itemPool.Waiters())
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		for i := 0; i < 3; i++ {
+			go itemPool.Borrow(timeoutCtx)
+		}
+		assert.Eventually(t, func() bool {
+			return itemPool.Waiters() == 3
+		}, time.Second, time.Millisecond)
+
+		// waiters leave once their context expires
+		assert.Eventually(t, func() bool {
+			return itemPool.Waiters() == 0
+		}, time.Second, time.Millisecond)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_PeakInUse(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should track and reset the high-water mark", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](5),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		var workers []*Worker
+		for i := 0; i < 3; i++ {
+			workers = append(workers, itemPool.Borrow(ctx))
+		}
+		for _, worker := range workers[1:] {
+			itemPool.ReturnItem(worker)
+		}
+		assert.Equal(t, 3, itemPool.PeakInUse())
+
+		itemPool.ResetPeak()
+		assert.Equal(t, 1, itemPool.PeakInUse())
+
+		worker := itemPool.Borrow(ctx)
+		assert.Equal(t, 2, itemPool.PeakInUse())
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(workers[0])
+	})
+}
+
+func TestPool_PublishExpvar(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should publish current stats", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+		)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: rand.Intn(1000)}
+		})
+		// tests may run several times in one process, expvar names must be unique
+		name := fmt.Sprintf("test_pool_%d", time.Now().UnixNano())
+		itemPool.PublishExpvar(name)
+
+		worker := itemPool.Borrow(ctx)
+		var stats sync.Stats
+		err := json.Unmarshal([]byte(expvar.Get(name).String()), &stats)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, stats.InUse)
+		assert.Equal(t, 2, stats.MaxSize)
+		itemPool.ReturnItem(worker)
+	})
+}
+
+func TestPool_HitsMisses(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should count idle items and factory calls", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		assert.Equal(t, uint64(1), itemPool.Hits())
+		assert.Equal(t, uint64(1), itemPool.Misses())
+	})
+	t.Run("should add up to all borrows under concurrency", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		done := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			go func() {
+				for j := 0; j < 100; j++ {
+					itemPool.ReturnItem(itemPool.Borrow(ctx))
+				}
+				done <- struct{}{}
+			}()
+		}
+		for i := 0; i < 8; i++ {
+			<-done
+		}
+		assert.Equal(t, uint64(800), itemPool.Hits()+itemPool.Misses())
+		assert.Equal(t, itemPool.Stats().Created, itemPool.Misses())
+	})
+}
+
+func TestPool_IdleItems(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should list idle items without borrowing them", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1 := itemPool.Borrow(ctx)
+		worker2 := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker1)
+		assert.Equal(t, []*Worker{worker1}, itemPool.IdleItems())
+		assert.Equal(t, 1, itemPool.Stats().Idle)
+
+		itemPool.ReturnItem(worker2)
+		assert.ElementsMatch(t, []*Worker{worker1, worker2}, itemPool.IdleItems())
+	})
+	t.Run("should list idle items across shards", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithShards[*Worker](4),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithBootstrapItems[*Worker](3),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Len(t, itemPool.IdleItems(), 3)
+	})
+	t.Run("should return nil for ephemeral pools", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithBootstrapItems[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		assert.Nil(t, itemPool.IdleItems())
+	})
+}
+
+func TestPool_AvgWaitTime(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should average the waits for slots", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithWaitSmoothing[*Worker](1),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.Equal(t, time.Duration(0), itemPool.AvgWaitTime())
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			itemPool.ReturnItem(worker)
+		}()
+		worker = itemPool.Borrow(ctx)
+		assert.GreaterOrEqual(t, itemPool.AvgWaitTime(), 20*time.Millisecond)
+
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		assert.Equal(t, time.Duration(0), itemPool.AvgWaitTime())
+	})
+	t.Run("should smooth out single waits", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithWaitSmoothing[*Worker](0.5),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			itemPool.ReturnItem(worker)
+		}()
+		worker = itemPool.Borrow(ctx)
+		waited := itemPool.AvgWaitTime()
+		assert.GreaterOrEqual(t, waited, 10*time.Millisecond)
+
+		itemPool.ReturnItem(worker)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		assert.InDelta(t, waited/2, itemPool.AvgWaitTime(), 1)
+	})
+	t.Run("should reject smoothing factors out of range", func(t *testing.T) {
+		_, err := sync.NewPoolErr[*Worker](sync.WithWaitSmoothing[*Worker](1.5))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+}
+
+func TestPool_WaitHistogram(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should count waits into buckets", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithWaitBuckets[*Worker]([]time.Duration{time.Millisecond, time.Hour}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			itemPool.ReturnItem(worker)
+		}()
+		worker = itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+
+		histogram := itemPool.WaitHistogram()
+		assert.Len(t, histogram, 3)
+		assert.Equal(t, time.Millisecond, histogram[0].UpperBound)
+		assert.Equal(t, uint64(1), histogram[0].Count)
+		assert.Equal(t, uint64(1), histogram[1].Count)
+		assert.Equal(t, uint64(0), histogram[2].Count)
+	})
+	t.Run("should return nil without buckets", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](sync.WithSize[*Worker](1))
+		assert.Nil(t, itemPool.WaitHistogram())
+	})
+	t.Run("should reject unordered buckets", func(t *testing.T) {
+		_, err := sync.NewPoolErr[*Worker](
+			sync.WithWaitBuckets[*Worker]([]time.Duration{time.Second, time.Millisecond}),
+		)
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+}
+
+func TestPool_WithMinReuse(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should wait briefly for a return rather than create", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithMinReuse[*Worker](0.9),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		go func() {
+			time.Sleep(2 * time.Millisecond)
+			itemPool.ReturnItem(worker)
+		}()
+		assert.Same(t, worker, itemPool.Borrow(ctx))
+		stats := itemPool.Stats()
+		assert.Equal(t, uint64(1), stats.Created)
+		assert.Equal(t, 0.5, stats.ReuseRatio)
+	})
+	t.Run("should create once the brief wait is over", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithMinReuse[*Worker](0.9),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		assert.NotSame(t, worker, itemPool.Borrow(ctx))
+		stats := itemPool.Stats()
+		assert.Equal(t, uint64(2), stats.Created)
+		assert.Equal(t, 0.0, stats.ReuseRatio)
+	})
+	t.Run("should reject a ratio above 1", func(t *testing.T) {
+		_, err := sync.NewPoolErr[*Worker](sync.WithMinReuse[*Worker](1.5))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+}
+
+func TestPool_AvgCreateTime(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should time every run of the factory", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithCreateTiming[*Worker](),
+		)
+		fail := true
+		itemPool.SetFactoryErr(ctx, func() (any, error) {
+			time.Sleep(5 * time.Millisecond)
+			if fail {
+				return nil, fmt.Errorf("dial failed")
+			}
+			return &Worker{id: rand.Intn(1000)}, nil
+		})
+		_, err := itemPool.BorrowErr(ctx)
+		assert.Error(t, err)
+		fail = false
+		itemPool.Borrow(ctx)
+		itemPool.Borrow(ctx)
+
+		assert.Equal(t, uint64(3), itemPool.CreateCount())
+		assert.GreaterOrEqual(t, itemPool.AvgCreateTime(), 5*time.Millisecond)
+	})
+	t.Run("should not time the factory by default", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		itemPool.Borrow(ctx)
+		assert.Equal(t, uint64(0), itemPool.CreateCount())
+		assert.Equal(t, time.Duration(0), itemPool.AvgCreateTime())
+	})
+}
+
+func TestPool_String(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should summarize the pool", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](10),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		workers, err := itemPool.BorrowBatch(ctx, 5)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(workers[:3])
+		assert.Equal(t, "Pool[*sync_test.Worker]{idle=3 inUse=2 max=10 waiting=0}", itemPool.String())
+		assert.Equal(t, itemPool.String(), fmt.Sprint(itemPool))
+	})
+	t.Run("should name interface item types", func(t *testing.T) {
+		itemPool := sync.NewPool[any]()
+		assert.Equal(t, "Pool[interface {}]{idle=0 inUse=0 max=0 waiting=0}", itemPool.String())
+	})
+}
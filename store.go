@@ -0,0 +1,289 @@
+package sync
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store holds the idle items of a Pool, for pools which need a policy of
+// their own such as LRU or NUMA-aware stores. It is set with WithStore.
+//
+// A Store must be safe for concurrent use. It must hand every item it was
+// given back through Get, or the pool keeps counting items it dropped;
+// only a SyncPoolStore, or a Store embedding one, may drop items.
+type Store[T any] interface {
+	// Get takes an idle item out of the store, if there is one.
+	Get() (T, bool)
+	// Put makes an item available to the next borrower.
+	Put(item T)
+	// Len returns the number of items in the store.
+	Len() int
+}
+
+// WithStore keeps the idle items of the pool in s instead of the store of
+// its RetentionMode, while the pool still enforces its size, runs its
+// hooks and reports its metrics. A Store belongs to a single pool. The
+// built-in SliceStore and SyncPoolStore are ready to be wrapped by stores
+// adding to their policy.
+func WithStore[T any](s Store[T]) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.custom = s
+	}
+}
+
+// store holds the idle items of a Pool.
+type store[T any] interface {
+	// get takes an idle item out of the store, if there is one.
+	get() (*entry[T], bool)
+	// put makes an item available to the next borrower.
+	put(e *entry[T])
+}
+
+// droppingStore is implemented by stores whose items may be dropped, such
+// as SyncPoolStore, so that the pool can account for dropped items.
+type droppingStore[T any] interface {
+	// setOnDrop makes the store call onDrop for every dropped item.
+	setOnDrop(onDrop func(e *entry[T]))
+}
+
+// expiringStore is implemented by stores which can enumerate their idle
+// items, allowing the pool to evict the ones idle for too long.
+type expiringStore[T any] interface {
+	// expire removes the items idle since before cutoff. It also reports
+	// since when the oldest remaining item is idle, zero if none is left.
+	expire(cutoff time.Time) (expired []*entry[T], oldest time.Time)
+}
+
+// listingStore is implemented by stores which can list their idle items
+// without handing them out.
+type listingStore[T any] interface {
+	// items returns a copy of the idle items.
+	items() []T
+}
+
+// SyncPoolStore is a Store keeping its items in a sync.Pool, the store
+// of Ephemeral retention. The garbage collector may drop idle items at any
+// time, a finalizer on idle entries lets the pool account for that: Len
+// no longer counts dropped items, and neither does a pool using the store.
+type SyncPoolStore[T any] struct {
+	pool   sync.Pool
+	len    atomic.Int64
+	onDrop func(e *entry[T])
+}
+
+// NewSyncPoolStore creates an empty SyncPoolStore.
+func NewSyncPoolStore[T any]() *SyncPoolStore[T] {
+	return newSyncPoolStore[T](nil)
+}
+
+func newSyncPoolStore[T any](onDrop func(e *entry[T])) *SyncPoolStore[T] {
+	return &SyncPoolStore[T]{onDrop: onDrop}
+}
+
+// Get takes an item out of the store.
+func (s *SyncPoolStore[T]) Get() (T, bool) {
+	e, ok := s.get()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return e.item, true
+}
+
+// Put adds an item to the store.
+func (s *SyncPoolStore[T]) Put(item T) {
+	s.put(&entry[T]{item: item})
+}
+
+// Len returns the number of items in the store.
+func (s *SyncPoolStore[T]) Len() int {
+	return int(s.len.Load())
+}
+
+func (s *SyncPoolStore[T]) get() (*entry[T], bool) {
+	e, ok := s.pool.Get().(*entry[T])
+	if !ok {
+		return nil, false
+	}
+	runtime.SetFinalizer(e, nil)
+	s.len.Add(-1)
+	return e, true
+}
+
+func (s *SyncPoolStore[T]) put(e *entry[T]) {
+	s.len.Add(1)
+	runtime.SetFinalizer(e, func(e *entry[T]) {
+		s.len.Add(-1)
+		if s.onDrop != nil {
+			s.onDrop(e)
+		}
+	})
+	s.pool.Put(e)
+}
+
+// setOnDrop makes the store call onDrop for every item the garbage
+// collector drops.
+func (s *SyncPoolStore[T]) setOnDrop(onDrop func(e *entry[T])) {
+	s.onDrop = onDrop
+}
+
+// SliceStore is a Store keeping its items in a slice the garbage collector
+// can't empty, the store of Deterministic retention. It hands out the most
+// recently returned item first, or the least recently returned one in FIFO
+// order.
+type SliceStore[T any] struct {
+	mu      sync.Mutex
+	entries []*entry[T]
+	fifo    bool
+}
+
+// NewSliceStore creates an empty SliceStore in LIFO order.
+func NewSliceStore[T any]() *SliceStore[T] {
+	return newSliceStore[T](LIFO)
+}
+
+func newSliceStore[T any](ordering Ordering) *SliceStore[T] {
+	return &SliceStore[T]{fifo: ordering == FIFO}
+}
+
+// Get takes the next item out of the store.
+func (s *SliceStore[T]) Get() (T, bool) {
+	e, ok := s.get()
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return e.item, true
+}
+
+// Put adds an item to the store.
+func (s *SliceStore[T]) Put(item T) {
+	s.put(&entry[T]{item: item})
+}
+
+// Len returns the number of items in the store.
+func (s *SliceStore[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *SliceStore[T]) get() (*entry[T], bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.entries)
+	if n == 0 {
+		return nil, false
+	}
+	if s.fifo {
+		e := s.entries[0]
+		s.entries[0] = nil
+		s.entries = s.entries[1:]
+		return e, true
+	}
+	e := s.entries[n-1]
+	s.entries[n-1] = nil
+	s.entries = s.entries[:n-1]
+	return e, true
+}
+
+func (s *SliceStore[T]) put(e *entry[T]) {
+	s.mu.Lock()
+	s.entries = append(s.entries, e)
+	s.mu.Unlock()
+}
+
+func (s *SliceStore[T]) expire(cutoff time.Time) ([]*entry[T], time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expired []*entry[T]
+	var oldest time.Time
+	kept := s.entries[:0]
+	for _, e := range s.entries {
+		if e.idleSince.Before(cutoff) {
+			expired = append(expired, e)
+			continue
+		}
+		if oldest.IsZero() || e.idleSince.Before(oldest) {
+			oldest = e.idleSince
+		}
+		kept = append(kept, e)
+	}
+	for i := len(kept); i < len(s.entries); i++ {
+		s.entries[i] = nil
+	}
+	s.entries = kept
+	return expired, oldest
+}
+
+func (s *SliceStore[T]) items() []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]T, len(s.entries))
+	for i, e := range s.entries {
+		items[i] = e.item
+	}
+	return items
+}
+
+// customStore keeps the idle items of a pool in a Store. Entries of items
+// which can be told apart are kept aside while their items are in the
+// store, other items get a fresh entry when they are taken out.
+type customStore[T any] struct {
+	store   Store[T]
+	fresh   func(item T) *entry[T]
+	mu      sync.Mutex
+	entries map[any]*entry[T]
+}
+
+// newCustomStore adapts store for a pool. If the store may drop items,
+// onDrop is called with their entries.
+func newCustomStore[T any](store Store[T], fresh func(item T) *entry[T], onDrop func(e *entry[T])) *customStore[T] {
+	s := &customStore[T]{store: store, fresh: fresh, entries: make(map[any]*entry[T])}
+	if ds, ok := store.(droppingStore[T]); ok {
+		ds.setOnDrop(func(e *entry[T]) {
+			onDrop(s.forget(e.item, e))
+		})
+	}
+	return s
+}
+
+func (s *customStore[T]) get() (*entry[T], bool) {
+	item, ok := s.store.Get()
+	if !ok {
+		return nil, false
+	}
+	return s.forget(item, nil), true
+}
+
+// forget removes the entry kept aside for item and returns it, or
+// otherwise e, or a fresh entry if e is nil.
+func (s *customStore[T]) forget(item T, e *entry[T]) *entry[T] {
+	if key := any(item); hasIdentity(key) {
+		s.mu.Lock()
+		kept, ok := s.entries[key]
+		delete(s.entries, key)
+		s.mu.Unlock()
+		if ok {
+			return kept
+		}
+	}
+	if e == nil {
+		e = s.fresh(item)
+	}
+	return e
+}
+
+func (s *customStore[T]) put(e *entry[T]) {
+	if key := any(e.item); hasIdentity(key) {
+		s.mu.Lock()
+		s.entries[key] = e
+		s.mu.Unlock()
+	}
+	s.store.Put(e.item)
+}
@@ -0,0 +1,105 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// countingStore is a Store counting puts on top of a SliceStore.
+type countingStore[T any] struct {
+	*sync.SliceStore[T]
+	puts int
+}
+
+func (s *countingStore[T]) Put(item T) {
+	s.puts++
+	s.SliceStore.Put(item)
+}
+
+func TestPool_WithStore(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should keep idle items in the store", func(t *testing.T) {
+		store := &countingStore[*Worker]{SliceStore: sync.NewSliceStore[*Worker]()}
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithStore[*Worker](store),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, 1, store.puts)
+		assert.Equal(t, 1, store.Len())
+
+		assert.Same(t, worker, itemPool.Borrow(ctx))
+		assert.Equal(t, 0, store.Len())
+		itemPool.ReturnItem(worker)
+
+		itemPool.Purge()
+		assert.Equal(t, 0, store.Len())
+		assert.Equal(t, int32(0), itemPool.Count())
+	})
+	t.Run("should keep the bookkeeping of stored items", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithMaxUses[*Worker](2),
+			sync.WithStore[*Worker](sync.NewSliceStore[*Worker]()),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		assert.Same(t, worker, itemPool.Borrow(ctx))
+		itemPool.ReturnItem(worker)
+		assert.Equal(t, int32(0), itemPool.Count())
+	})
+	t.Run("should discount items dropped from a SyncPoolStore", func(t *testing.T) {
+		store := sync.NewSyncPoolStore[*Worker]()
+		itemPool := sync.NewPool[*Worker](
+			sync.WithStore[*Worker](store),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		itemPool.ReturnItem(itemPool.Borrow(ctx))
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			return itemPool.Count() == 0
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, 0, store.Len())
+		assert.Equal(t, 0, itemPool.Stats().Idle)
+	})
+}
+
+func TestSliceStore(t *testing.T) {
+	t.Run("should hand out the most recent item first", func(t *testing.T) {
+		store := sync.NewSliceStore[int]()
+		store.Put(1)
+		store.Put(2)
+		item, ok := store.Get()
+		assert.True(t, ok)
+		assert.Equal(t, 2, item)
+		assert.Equal(t, 1, store.Len())
+	})
+}
+
+func TestSyncPoolStore(t *testing.T) {
+	t.Run("should count items put and taken out", func(t *testing.T) {
+		store := sync.NewSyncPoolStore[*Worker]()
+		worker := &Worker{id: rand.Intn(1000)}
+		store.Put(worker)
+		assert.Equal(t, 1, store.Len())
+		if item, ok := store.Get(); ok {
+			assert.Same(t, worker, item)
+			assert.Equal(t, 0, store.Len())
+		}
+		_, ok := store.Get()
+		assert.False(t, ok)
+	})
+}
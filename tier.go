@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// WithOverflowTier adds a second tier of up to size items to a bounded
+// pool, for bursts beyond its size. Once every slot of the pool is taken,
+// borrowers get an overflow item instead of waiting, as long as fewer than
+// size of them are borrowed. Overflow items go back to a tier of their own
+// when returned and are destroyed once idle for longer than maxIdle, so the
+// pool shrinks back to its primary items when load drops; without a
+// maxIdle they only expire like primary items. Stats reports them apart.
+//
+// As for WithOverflow, only borrows of a single slot are served from the
+// tier, and only while no borrower is queued by priority.
+func WithOverflowTier[T any](size int, maxIdle time.Duration) PoolOption[T] {
+	return func(p *Pool[T]) {
+		if size < 1 {
+			p.invalid("overflow tier size must be at least 1, got %d", size)
+			return
+		}
+		p.tier = &overflowTier[T]{
+			sem:     semaphore.NewWeighted(int64(size)),
+			maxIdle: maxIdle,
+			idle:    newSliceStore[T](LIFO),
+		}
+	}
+}
+
+// overflowTier holds the items borrowed beyond the size of a pool.
+type overflowTier[T any] struct {
+	sem     *semaphore.Weighted
+	maxIdle time.Duration
+	idle    *SliceStore[T]
+
+	idleCount atomic.Int32
+	inUse     atomic.Int32
+	anonymous atomic.Int32 // anonymous counts borrowed tier items without identity
+}
+
+// borrowTier obtains an item for a slot of the overflow tier, giving the
+// slot back on failure.
+func (p *Pool[T]) borrowTier(ctx context.Context) (*entry[T], error) {
+	e, err := p.getTier(ctx)
+	if err != nil {
+		p.tier.sem.Release(1)
+		return nil, err
+	}
+	p.tier.inUse.Add(1)
+	p.raisePeak(p.inUse.Add(1), 1)
+	return e, nil
+}
+
+// getTier takes an idle overflow item, creating one if none is idle.
+func (p *Pool[T]) getTier(ctx context.Context) (*entry[T], error) {
+	for {
+		e, ok := p.tier.idle.get()
+		if !ok {
+			break
+		}
+		p.tier.idleCount.Add(-1)
+		if p.usable(e) && !p.tier.expired(e, time.Now()) {
+			p.hits.Add(1)
+			return e, nil
+		}
+		p.destroy(e)
+	}
+	p.misses.Add(1)
+	e, err := p.create(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.tier = true
+	p.extra.Add(1)
+	return e, nil
+}
+
+// returnTier takes back a borrowed overflow item, keeping it idle in the
+// tier unless destroy is set.
+func (p *Pool[T]) returnTier(e *entry[T], destroy bool) {
+	if destroy || p.closed.Load() {
+		p.destroy(e)
+	} else {
+		e.idleSince = time.Now()
+		p.tier.idleCount.Add(1)
+		p.tier.idle.put(e)
+		if p.closed.Load() {
+			// lost a race with Close, make sure item does not linger
+			p.drain()
+		}
+	}
+	p.tier.inUse.Add(-1)
+	p.unborrow(1)
+	p.tier.sem.Release(1)
+}
+
+// takeTier reports whether a returned item without identity is counted as
+// one of the borrowed overflow tier items, which are all alike.
+func (p *Pool[T]) takeTier() bool {
+	for {
+		n := p.tier.anonymous.Load()
+		if n <= 0 {
+			return false
+		}
+		if p.tier.anonymous.CompareAndSwap(n, n-1) {
+			return true
+		}
+	}
+}
+
+// expired reports whether an idle overflow item has been idle for too long.
+func (t *overflowTier[T]) expired(e *entry[T], now time.Time) bool {
+	return t.maxIdle > 0 && now.Sub(e.idleSince) > t.maxIdle
+}
+
+// drainTier destroys the idle overflow items.
+func (p *Pool[T]) drainTier() []error {
+	var errs []error
+	for {
+		e, ok := p.tier.idle.get()
+		if !ok {
+			return errs
+		}
+		p.tier.idleCount.Add(-1)
+		if err := p.destroy(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestPool_WithOverflowTier(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should serve from the tier once the pool is full", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflowTier[*Worker](2, 0),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		primary := itemPool.Borrow(ctx)
+		overflow1 := itemPool.Borrow(ctx)
+		overflow2 := itemPool.Borrow(ctx)
+		assert.NotNil(t, overflow2)
+		stats := itemPool.Stats()
+		assert.Equal(t, 1, stats.InUse)
+		assert.Equal(t, 2, stats.OverflowInUse)
+
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+
+		itemPool.ReturnItem(overflow1)
+		itemPool.ReturnItem(overflow2)
+		stats = itemPool.Stats()
+		assert.Equal(t, 0, stats.OverflowInUse)
+		assert.Equal(t, 2, stats.OverflowIdle)
+		assert.Equal(t, 0, stats.Idle)
+
+		// idle tier items are handed out again
+		again := itemPool.Borrow(ctx)
+		assert.Contains(t, []*Worker{overflow1, overflow2}, again)
+		itemPool.ReturnItem(again)
+		itemPool.ReturnItem(primary)
+		stats = itemPool.Stats()
+		assert.Equal(t, 1, stats.Idle)
+		assert.Equal(t, 2, stats.OverflowIdle)
+		assert.NoError(t, itemPool.Close())
+		assert.Equal(t, uint64(3), itemPool.Stats().Destroyed)
+	})
+	t.Run("should keep a returned primary item while overflow items are out", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflowTier[*Worker](1, 0),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		primary := itemPool.Borrow(ctx)
+		overflow := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(primary)
+		assert.Equal(t, 1, itemPool.Stats().Idle)
+		assert.Equal(t, uint64(0), itemPool.Stats().Destroyed)
+		itemPool.ReturnItem(overflow)
+	})
+	t.Run("should destroy overflow items idle for longer than maxIdle", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflowTier[*Worker](1, 20*time.Millisecond),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		defer itemPool.Close()
+		primary := itemPool.Borrow(ctx)
+		overflow := itemPool.Borrow(ctx)
+		itemPool.ReturnItem(overflow)
+		itemPool.ReturnItem(primary)
+		assert.Eventually(t, func() bool {
+			return itemPool.Stats().OverflowIdle == 0
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, 1, itemPool.Stats().Idle)
+		assert.Equal(t, int32(1), itemPool.Count())
+	})
+	t.Run("should free the tier slot when the hook panics on a tier item", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflowTier[*Worker](1, 0),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithOnBorrow[*Worker](func(w *Worker) {
+				if w.id == 0 {
+					panic("bad worker")
+				}
+			}),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: 1}
+			}),
+		)
+		primary := itemPool.Borrow(ctx)
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 0}
+		})
+		assert.Panics(t, func() {
+			itemPool.Borrow(ctx)
+		})
+		stats := itemPool.Stats()
+		assert.Equal(t, 1, stats.InUse)
+		assert.Equal(t, 0, stats.OverflowInUse)
+
+		itemPool.SetFactory(ctx, func() interface{} {
+			return &Worker{id: 1}
+		})
+		overflow, err := itemPool.BorrowTimeout(50 * time.Millisecond)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, itemPool.Stats().OverflowInUse)
+		itemPool.ReturnItem(overflow)
+		itemPool.ReturnItem(primary)
+	})
+	t.Run("should reject a tier on an unbounded pool", func(t *testing.T) {
+		_, err := sync.NewPoolErr[*Worker](sync.WithOverflowTier[*Worker](1, 0))
+		assert.ErrorIs(t, err, sync.ErrInvalidConfig)
+	})
+}
@@ -0,0 +1,58 @@
+package sync
+
+import (
+	"context"
+	"time"
+)
+
+// BorrowTracer traces the borrows of a pool, e.g. as spans of a
+// distributed trace. The otelsync module provides one for OpenTelemetry,
+// keeping it out of the core dependencies.
+type BorrowTracer interface {
+	// StartBorrow is called when a borrow starts waiting for an item. The
+	// returned context is passed on to a context aware factory, and done
+	// is called once the borrow got an item or failed.
+	StartBorrow(ctx context.Context) (_ context.Context, done func(BorrowTrace))
+}
+
+// BorrowTrace describes a finished borrow to a BorrowTracer.
+type BorrowTrace struct {
+	// Waited is for how long the borrow waited for a slot to free up.
+	Waited time.Duration
+	// Created tells whether the item is handed out for the first time
+	// since the factory created it, see BorrowNew.
+	Created bool
+	// Err is why the borrow failed, nil if it got an item.
+	Err error
+}
+
+// WithTracer traces every borrow of a single item with t, batches aside.
+func WithTracer[T any](t BorrowTracer) PoolOption[T] {
+	return func(p *Pool[T]) {
+		p.tracer = t
+	}
+}
+
+// tracedBorrow borrows like borrow, reporting to the tracer if one is set.
+func (p *Pool[T]) tracedBorrow(ctx context.Context, req borrowReq) (*entry[T], error) {
+	if p.tracer == nil {
+		e, err := p.borrow(ctx, req)
+		return e, aborted(err)
+	}
+	ctx, done := p.tracer.StartBorrow(ctx)
+	start := time.Now()
+	s, err := p.admit(ctx, req)
+	err = aborted(err)
+	trace := BorrowTrace{Waited: time.Since(start), Err: err}
+	if err != nil {
+		done(trace)
+		return nil, err
+	}
+	e, err := p.obtain(ctx, req, s)
+	err = aborted(err)
+	trace.Err = err
+	// new items have never been handed out
+	trace.Created = err == nil && e.uses == 0
+	done(trace)
+	return e, err
+}
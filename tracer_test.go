@@ -0,0 +1,97 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+type recordingTracer struct {
+	traces []sync.BorrowTrace
+}
+
+func (r *recordingTracer) StartBorrow(ctx context.Context) (context.Context, func(sync.BorrowTrace)) {
+	return ctx, func(trace sync.BorrowTrace) {
+		r.traces = append(r.traces, trace)
+	}
+}
+
+func TestPool_WithTracer(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should trace borrows", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithTracer[*Worker](tracer),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: 1}
+			}),
+		)
+		worker := itemPool.Borrow(ctx)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			itemPool.ReturnItem(worker)
+		}()
+		worker = itemPool.Borrow(ctx)
+		itemPool.ReturnItem(worker)
+		assert.NoError(t, itemPool.Close())
+		_, err := itemPool.BorrowErr(ctx)
+		assert.ErrorIs(t, err, sync.ErrPoolClosed)
+
+		assert.Len(t, tracer.traces, 3)
+		assert.True(t, tracer.traces[0].Created)
+		assert.False(t, tracer.traces[1].Created)
+		assert.GreaterOrEqual(t, tracer.traces[1].Waited, 20*time.Millisecond)
+		assert.ErrorIs(t, tracer.traces[2].Err, sync.ErrPoolClosed)
+	})
+	t.Run("should trace overflow items like other borrows", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](1),
+			sync.WithOverflow[*Worker](true),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithTracer[*Worker](tracer),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker1 := itemPool.Borrow(ctx)
+		worker2, err := itemPool.BorrowTimeout(50 * time.Millisecond)
+		assert.NoError(t, err)
+		assert.Len(t, tracer.traces, 2)
+		assert.True(t, tracer.traces[1].Created)
+		itemPool.ReturnItem(worker2)
+		itemPool.ReturnItem(worker1)
+		assert.Equal(t, int32(1), itemPool.Count())
+	})
+	t.Run("should trace every kind of single item borrow", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](2),
+			sync.WithRetentionMode[*Worker](sync.Deterministic),
+			sync.WithTracer[*Worker](tracer),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: rand.Intn(1000)}
+			}),
+		)
+		worker, ok := itemPool.TryBorrow()
+		assert.True(t, ok)
+		itemPool.ReturnItem(worker)
+		worker, err := itemPool.BorrowSoft(ctx, time.Millisecond)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+		worker, err = itemPool.BorrowAffine(ctx, worker)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+		worker, err = itemPool.BorrowWeighted(ctx, 2)
+		assert.NoError(t, err)
+		itemPool.ReturnWeighted(worker, 2)
+		assert.Len(t, tracer.traces, 4)
+		assert.True(t, tracer.traces[0].Created)
+		assert.False(t, tracer.traces[3].Created)
+	})
+}
@@ -0,0 +1,37 @@
+package sync
+
+import "context"
+
+// BorrowWeighted obtains an item like BorrowErr, counting it as w items
+// against the max size of the pool, e.g. the size in KiB of a buffer
+// against a memory budget set with WithSize. It blocks until w slots are
+// free. Borrow is BorrowWeighted with a weight of 1, and weights below 1
+// count as 1.
+//
+// Items which can be told apart, see NewPointerPool, remember their weight:
+// ReturnItem, Discard and Replace give all w slots back. Other items must
+// be returned with ReturnWeighted using the same w.
+//
+// ErrBatchTooLarge is returned if w exceeds the max size of the pool.
+// InUse and Idle in Stats still count items, not their weight.
+func (p *Pool[T]) BorrowWeighted(ctx context.Context, w int64) (T, error) {
+	var zero T
+	if w < 1 {
+		w = 1
+	}
+	if size := p.size.Load(); size > 0 && w > int64(size) {
+		return zero, ErrBatchTooLarge
+	}
+	return p.borrowItem(ctx, borrowReq{weight: w})
+}
+
+// ReturnWeighted returns an item obtained with BorrowWeighted like
+// ReturnItem does, giving its w slots back. w is only needed for items
+// which can't be told apart, others give back the weight they were
+// borrowed with.
+func (p *Pool[T]) ReturnWeighted(item T, w int64) {
+	if w < 1 {
+		w = 1
+	}
+	p.giveBack(item, w, false)
+}
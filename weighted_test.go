@@ -0,0 +1,100 @@
+package sync_test
+
+import (
+	"context"
+	"github.com/kushsharma/go-sync"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPool_BorrowWeighted(t *testing.T) {
+	ctx := context.Background()
+	t.Run("should enforce the size as total weight", func(t *testing.T) {
+		itemPool := sync.NewPool[[]byte](
+			sync.WithSize[[]byte](8),
+			sync.WithFactory[[]byte](func() []byte {
+				return make([]byte, 1024)
+			}),
+		)
+		big, err := itemPool.BorrowWeighted(ctx, 6)
+		assert.NoError(t, err)
+		small, err := itemPool.BorrowWeighted(ctx, 2)
+		assert.NoError(t, err)
+		_, ok := itemPool.TryBorrow()
+		assert.False(t, ok)
+
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = itemPool.BorrowWeighted(timeout, 3)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		itemPool.ReturnWeighted(big, 6)
+		medium, err := itemPool.BorrowWeighted(ctx, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, itemPool.Stats().InUse)
+		itemPool.ReturnWeighted(medium, 3)
+		itemPool.ReturnWeighted(small, 2)
+
+		batch, err := itemPool.BorrowBatch(ctx, 8)
+		assert.NoError(t, err)
+		itemPool.ReturnBatch(batch)
+	})
+	t.Run("should free the weight of a discarded item", func(t *testing.T) {
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+			sync.WithFactory[*Worker](func() *Worker {
+				return &Worker{id: 1}
+			}),
+		)
+		worker, err := itemPool.BorrowWeighted(ctx, 4)
+		assert.NoError(t, err)
+		itemPool.Discard(worker)
+
+		worker, err = itemPool.BorrowTimeout(20 * time.Millisecond)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+		worker, err = itemPool.BorrowWeighted(ctx, 4)
+		assert.NoError(t, err)
+		itemPool.ReturnItem(worker)
+		_, err = itemPool.BorrowBatch(ctx, 4)
+		assert.NoError(t, err)
+	})
+	t.Run("should keep the weight of a replaced item", func(t *testing.T) {
+		fail := false
+		itemPool := sync.NewPool[*Worker](
+			sync.WithSize[*Worker](4),
+		)
+		itemPool.SetFactoryErr(ctx, func() (any, error) {
+			if fail {
+				return nil, assert.AnError
+			}
+			return &Worker{id: 1}, nil
+		})
+		worker, err := itemPool.BorrowWeighted(ctx, 3)
+		assert.NoError(t, err)
+		replacement, err := itemPool.Replace(ctx, worker)
+		assert.NoError(t, err)
+		timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = itemPool.BorrowWeighted(timeout, 2)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		fail = true
+		_, err = itemPool.Replace(ctx, replacement)
+		assert.ErrorIs(t, err, assert.AnError)
+		fail = false
+		_, err = itemPool.BorrowBatch(ctx, 4)
+		assert.NoError(t, err)
+	})
+	t.Run("should reject weights beyond the size", func(t *testing.T) {
+		itemPool := sync.NewPool[[]byte](
+			sync.WithSize[[]byte](4),
+			sync.WithFactory[[]byte](func() []byte {
+				return make([]byte, 1024)
+			}),
+		)
+		_, err := itemPool.BorrowWeighted(ctx, 5)
+		assert.ErrorIs(t, err, sync.ErrBatchTooLarge)
+	})
+}